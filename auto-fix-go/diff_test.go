@@ -0,0 +1,51 @@
+package main
+
+import "testing"
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new string
+		want     string
+	}{
+		{"equal", "a\nb\n", "a\nb\n", ""},
+		{
+			"change",
+			"a\nb\nc\nd\ne\nf\ng\nh\n",
+			"a\nb\nc\nD\ne\nf\ng\nh\n",
+			"--- a/f.go\n+++ b/f.go\n@@ -1,7 +1,7 @@\n a\n b\n c\n-d\n+D\n e\n f\n g\n",
+		},
+		{
+			"create",
+			"",
+			"x\ny\n",
+			"--- a/f.go\n+++ b/f.go\n@@ -0,0 +1,2 @@\n+x\n+y\n",
+		},
+		{
+			"delete",
+			"x\n",
+			"",
+			"--- a/f.go\n+++ b/f.go\n@@ -1 +0,0 @@\n-x\n",
+		},
+		{
+			"no trailing newline",
+			"a\nb",
+			"a\nc",
+			"--- a/f.go\n+++ b/f.go\n@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+c\n\\ No newline at end of file\n",
+		},
+		{
+			"two hunks",
+			"1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n",
+			"0\n1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n",
+			"--- a/f.go\n+++ b/f.go\n@@ -1,3 +1,4 @@\n+0\n 1\n 2\n 3\n" +
+				"@@ -9,4 +10,3 @@\n 9\n 10\n 11\n-12\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := unifiedDiff("f.go", tt.old, tt.new); got != tt.want {
+				t.Errorf("unifiedDiff =\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// files maps the slash-separated paths of a project's Go files,
+// relative to the project directory, to their contents.
+type files map[string]string
+
+// snapshot reads the Go files under dir.
+func snapshot(dir string) (files, error) {
+	fs := make(files)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !strings.HasSuffix(path, ".go") {
+			return nil
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		fs[filepath.ToSlash(rel)] = string(content)
+		return nil
+	})
+	return fs, err
+}
+
+func (fs files) names() []string {
+	names := make([]string, 0, len(fs))
+	for name := range fs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// String formats fs with the "=== path ===" file headers used in the
+// prompt and expected in the model's reply.
+func (fs files) String() string {
+	var sb strings.Builder
+	for _, name := range fs.names() {
+		fmt.Fprintf(&sb, "=== %s ===\n%s\n\n", name, fs[name])
+	}
+	return sb.String()
+}
+
+// diff returns a unified diff of the changes from fs to other.
+func (fs files) diff(other files) string {
+	all := make(files)
+	for name := range fs {
+		all[name] = ""
+	}
+	for name := range other {
+		all[name] = ""
+	}
+	var sb strings.Builder
+	for _, name := range all.names() {
+		sb.WriteString(unifiedDiff(name, fs[name], other[name]))
+	}
+	return sb.String()
+}
+
+// restore rewrites the files under dir to match fs, removing Go files
+// that fs does not contain.
+func (fs files) restore(dir string) error {
+	current, err := snapshot(dir)
+	if err != nil {
+		return err
+	}
+	for name := range current {
+		if _, ok := fs[name]; !ok {
+			if err := os.Remove(filepath.Join(dir, filepath.FromSlash(name))); err != nil {
+				return err
+			}
+		}
+	}
+	for name, content := range fs {
+		if current[name] == content {
+			continue
+		}
+		if err := os.WriteFile(filepath.Join(dir, filepath.FromSlash(name)), []byte(content), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -4,55 +4,82 @@ import (
 	"bufio"
 	"context"
 	_ "embed"
+	"errors"
+	"flag"
 	"fmt"
-	"io/ioutil"
 	"os"
-	"os/exec"
+	"path"
 	"path/filepath"
 	"strings"
 
 	"github.com/tmc/langchaingo/llms"
-	"github.com/tmc/langchaingo/llms/anthropic"
 )
 
 //go:embed system-prompt.txt
 var systemPrompt string
 
+var (
+	maxIterations = flag.Int("max-iterations", 5, "maximum number of fix attempts")
+	keepChanges   = flag.Bool("keep", false, "keep the last attempt's changes if tests still fail, instead of reverting")
+	reportFile    = flag.String("report", "", "also write the final report to this file")
+
+	providerFlag = flag.String("provider", envOr("AUTO_FIX_GO_PROVIDER", defaultProvider), "model provider: anthropic, openai, or ollama (env AUTO_FIX_GO_PROVIDER)")
+	baseURLFlag  = flag.String("base-url", os.Getenv("AUTO_FIX_GO_BASE_URL"), "base URL of the provider's API, if not the default (env AUTO_FIX_GO_BASE_URL)")
+	modelFlag    = flag.String("model", os.Getenv("AUTO_FIX_GO_MODEL"), "model name, if not the provider's default (env AUTO_FIX_GO_MODEL)")
+)
+
 func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: %s [flags] <directory>\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
 	if err := run(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 }
 
-func run() error {
-	if len(os.Args) != 2 {
-		return fmt.Errorf("usage: %s <directory>", os.Args[0])
+func run() (err error) {
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *maxIterations < 1 {
+		return fmt.Errorf("-max-iterations must be at least 1")
 	}
 
-	dir := os.Args[1]
+	dir := flag.Arg(0)
 	ctx := context.Background()
 
-	client, err := anthropic.New()
+	client, err := newModel(*providerFlag, *baseURLFlag, *modelFlag)
 	if err != nil {
-		return fmt.Errorf("failed to create Anthropic client: %w", err)
+		return fmt.Errorf("failed to create %s client: %w", *providerFlag, err)
 	}
 
-	for {
-		testsPassed, testOutput := runTests(dir)
-		if testsPassed {
-			fmt.Println("All tests passed. Exiting.")
-			return nil
-		}
+	original, err := snapshot(dir)
+	if err != nil {
+		return fmt.Errorf("failed to read source files: %w", err)
+	}
+
+	rep := &report{maxIterations: *maxIterations}
+	// From here on, an attempt may have modified the tree, so every
+	// return goes through finish to revert it and write the report.
+	defer func() {
+		err = finish(dir, original, rep, err)
+	}()
 
-		fmt.Println("Tests failed. Attempting to fix...")
+	testsPassed, testOutput := runTests(dir, nil)
+	failing := failingTests(testOutput)
+	for !testsPassed && len(rep.attempts) < *maxIterations {
+		fmt.Printf("Tests failed. Attempting fix %d of %d...\n", len(rep.attempts)+1, *maxIterations)
 
-		sourceCode, err := readSourceFiles(dir)
+		before, err := snapshot(dir)
 		if err != nil {
 			return fmt.Errorf("failed to read source files: %w", err)
 		}
 
-		fixedCode, err := getFixedCode(ctx, client, sourceCode, testOutput)
+		fixedCode, err := getFixedCode(ctx, client, before.String(), testOutput)
 		if err != nil {
 			return fmt.Errorf("failed to get fixed code: %w", err)
 		}
@@ -61,37 +88,81 @@ func run() error {
 			return fmt.Errorf("failed to apply fixes: %w", err)
 		}
 
-		fmt.Println("Applied fixes. Retrying tests...")
-	}
-}
+		after, err := snapshot(dir)
+		if err != nil {
+			return fmt.Errorf("failed to read source files: %w", err)
+		}
 
-func runTests(dir string) (bool, string) {
-	cmd := exec.Command("go", "test", "./...")
-	cmd.Dir = dir
-	output, err := cmd.CombinedOutput()
-	return err == nil, string(output)
+		// Rerun only the tests that failed last time, if they are
+		// known, and widen to the full suite once they pass.
+		if len(failing) > 0 {
+			fmt.Printf("Running %d failing test(s): %s\n", len(failing), strings.Join(failing, ", "))
+			testsPassed, testOutput = runTests(dir, failing)
+		}
+		if len(failing) == 0 || testsPassed {
+			testsPassed, testOutput = runTests(dir, nil)
+		}
+		failing = failingTests(testOutput)
+		a := attempt{
+			diff:       before.diff(after),
+			passed:     testsPassed,
+			testOutput: testOutput,
+		}
+		rep.attempts = append(rep.attempts, a)
+		a.print(os.Stdout, len(rep.attempts))
+	}
+	rep.passed = testsPassed
+	return nil
 }
 
-func readSourceFiles(dir string) (string, error) {
-	var sourceCode strings.Builder
-
-	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
+// finish completes a run that stopped early with runErr, or that used
+// up its attempts if runErr is nil. Unless the tests pass or -keep is
+// set, it reverts dir to original, undoing any partly applied attempt.
+// It then prints the summary and writes the report file. It returns
+// runErr and any error from finishing, or an error if the tests still
+// fail.
+func finish(dir string, original files, rep *report, runErr error) error {
+	var errs []error
+	if runErr != nil {
+		errs = append(errs, runErr)
+	} else if !rep.passed {
+		errs = append(errs, fmt.Errorf("tests did not pass within %d iterations", rep.maxIterations))
+	}
+	if !rep.passed {
+		if *keepChanges {
+			rep.outcome = "Tests still failing; the last attempt's changes were kept (-keep)."
+		} else if err := original.restore(dir); err != nil {
+			errs = append(errs, fmt.Errorf("failed to revert to the original files: %w", err))
+			rep.outcome = "Tests still failing; reverting to the original files failed."
+		} else {
+			rep.outcome = "Tests still failing; all files were reverted to their original contents."
 		}
-		if !info.IsDir() && strings.HasSuffix(path, ".go") {
-			content, err := ioutil.ReadFile(path)
-			if err != nil {
-				return err
-			}
-			sourceCode.WriteString(fmt.Sprintf("=== %s ===\n", path))
-			sourceCode.Write(content)
-			sourceCode.WriteString("\n\n")
+		if runErr != nil {
+			rep.outcome = fmt.Sprintf("Stopped: %v\n%s", runErr, rep.outcome)
+		}
+	}
+
+	// Each attempt's diff has already been printed as it happened.
+	fmt.Println()
+	rep.write(os.Stdout, false)
+	if *reportFile != "" {
+		if err := writeReport(*reportFile, rep); err != nil {
+			errs = append(errs, err)
 		}
+	}
+	if len(errs) == 0 {
 		return nil
-	})
+	}
+	return errors.Join(errs...)
+}
 
-	return sourceCode.String(), err
+func writeReport(name string, rep *report) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	rep.write(f, true)
+	return f.Close()
 }
 
 func getFixedCode(ctx context.Context, client llms.Model, sourceCode, testOutput string) (string, error) {
@@ -110,32 +181,55 @@ func getFixedCode(ctx context.Context, client llms.Model, sourceCode, testOutput
 	return resp.Choices[0].Content, nil
 }
 
+// applyFixes writes the files in the model's reply, fixedCode, under
+// dir. Every file header is checked before anything is written.
 func applyFixes(dir string, fixedCode string) error {
+	fixes, err := parseFixes(fixedCode)
+	if err != nil {
+		return err
+	}
+	for _, name := range fixes.names() {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, []byte(fixes[name]), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseFixes splits a reply into its "=== path ===" sections. Each path
+// must be a .go file that stays within the project directory, so that
+// restore can undo the write.
+func parseFixes(fixedCode string) (files, error) {
+	fixes := make(files)
 	scanner := bufio.NewScanner(strings.NewReader(fixedCode))
 	var currentFile string
 	var fileContent strings.Builder
+	flush := func() {
+		if currentFile != "" {
+			fixes[currentFile] = fileContent.String()
+		}
+		fileContent.Reset()
+	}
 
 	for scanner.Scan() {
 		line := scanner.Text()
 		if strings.HasPrefix(line, "=== ") && strings.HasSuffix(line, " ===") {
-			if currentFile != "" {
-				if err := os.WriteFile(currentFile, []byte(fileContent.String()), 0644); err != nil {
-					return err
-				}
-				fileContent.Reset()
+			flush()
+			header := strings.TrimPrefix(strings.TrimSuffix(line, " ==="), "=== ")
+			name := path.Clean(filepath.ToSlash(header))
+			if !filepath.IsLocal(filepath.FromSlash(name)) || !strings.HasSuffix(name, ".go") {
+				return nil, fmt.Errorf("invalid file header %q: want a .go file within the project", header)
 			}
-			currentFile = filepath.Join(dir, strings.TrimPrefix(strings.TrimSuffix(line, " ==="), "=== "))
-		} else {
+			currentFile = name
+		} else if currentFile != "" {
 			fileContent.WriteString(line)
 			fileContent.WriteString("\n")
 		}
 	}
-
-	if currentFile != "" {
-		if err := os.WriteFile(currentFile, []byte(fileContent.String()), 0644); err != nil {
-			return err
-		}
-	}
-
-	return scanner.Err()
-}
\ No newline at end of file
+	flush()
+	return fixes, scanner.Err()
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFinishRevertsOnError(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("a.go", "package a\n")
+	original, err := snapshot(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A partly applied attempt: one file changed and one created.
+	write("a.go", "package a // edited\n")
+	write("b.go", "package a\n")
+
+	saved := *reportFile
+	defer func() { *reportFile = saved }()
+	*reportFile = filepath.Join(t.TempDir(), "report.txt")
+
+	rep := &report{maxIterations: 5}
+	runErr := errors.New("model unavailable")
+	if err := finish(dir, original, rep, runErr); !errors.Is(err, runErr) {
+		t.Errorf("finish() = %v, want %v", err, runErr)
+	}
+
+	got, err := snapshot(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := original.diff(got); d != "" {
+		t.Errorf("files not reverted:\n%s", d)
+	}
+	data, err := os.ReadFile(*reportFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "Stopped: model unavailable") {
+		t.Errorf("report does not mention the error:\n%s", data)
+	}
+}
+
+func TestParseFixes(t *testing.T) {
+	got, err := parseFixes("Here you go.\n=== a.go ===\npackage a\n=== sub/../b.go ===\npackage b\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got["a.go"] != "package a\n" || got["b.go"] != "package b\n" {
+		t.Errorf("parseFixes() = %q", got)
+	}
+
+	for _, header := range []string{"../../x.go", "/etc/x.go", "a/../../x.go", "go.mod"} {
+		if _, err := parseFixes("=== " + header + " ===\npackage x\n"); err == nil {
+			t.Errorf("parseFixes accepted header %q", header)
+		}
+	}
+}
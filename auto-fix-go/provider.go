@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/llms/anthropic"
+	"github.com/tmc/langchaingo/llms/ollama"
+	"github.com/tmc/langchaingo/llms/openai"
+)
+
+// providers are the model backends selectable with -provider, and
+// defaultProvider is the one used when neither -provider nor
+// AUTO_FIX_GO_PROVIDER is set.
+var providers = []string{"anthropic", "openai", "ollama"}
+
+const defaultProvider = "anthropic"
+
+// envOr returns the value of the environment variable key, or def if it
+// is unset or empty.
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+// newModel returns a client for the named provider. baseURL and model
+// override the provider's defaults when not empty. Everything else,
+// such as API keys, comes from the provider's usual environment
+// variables. Any server that speaks one of these APIs, such as a proxy
+// in front of Anthropic or an OpenAI-compatible local server, can be
+// used by setting baseURL.
+func newModel(provider, baseURL, model string) (llms.Model, error) {
+	switch provider {
+	case "anthropic":
+		var opts []anthropic.Option
+		if baseURL != "" {
+			opts = append(opts, anthropic.WithBaseURL(baseURL))
+		}
+		if model != "" {
+			opts = append(opts, anthropic.WithModel(model))
+		}
+		return anthropic.New(opts...)
+	case "openai":
+		var opts []openai.Option
+		if baseURL != "" {
+			opts = append(opts, openai.WithBaseURL(baseURL))
+		}
+		if model != "" {
+			opts = append(opts, openai.WithModel(model))
+		}
+		return openai.New(opts...)
+	case "ollama":
+		if model == "" {
+			return nil, fmt.Errorf("provider ollama requires -model")
+		}
+		opts := []ollama.Option{ollama.WithModel(model)}
+		if baseURL != "" {
+			opts = append(opts, ollama.WithServerURL(baseURL))
+		}
+		return ollama.New(opts...)
+	}
+	return nil, fmt.Errorf("unknown provider %q (want one of %v)", provider, providers)
+}
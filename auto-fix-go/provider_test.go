@@ -0,0 +1,60 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tmc/langchaingo/llms/anthropic"
+	"github.com/tmc/langchaingo/llms/ollama"
+	"github.com/tmc/langchaingo/llms/openai"
+)
+
+func TestNewModel(t *testing.T) {
+	// Constructing a client makes no requests, so fake keys suffice.
+	t.Setenv("ANTHROPIC_API_KEY", "test-key")
+	t.Setenv("OPENAI_API_KEY", "test-key")
+
+	tests := []struct {
+		provider, baseURL, model string
+		want                     any    // a nil client of the wanted type
+		wantErr                  string // if not empty, the error wanted
+	}{
+		{defaultProvider, "", "", (*anthropic.LLM)(nil), ""},
+		{"anthropic", "http://localhost:8080", "claude-test", (*anthropic.LLM)(nil), ""},
+		{"openai", "", "", (*openai.LLM)(nil), ""},
+		{"openai", "http://localhost:8080/v1", "gpt-test", (*openai.LLM)(nil), ""},
+		{"ollama", "http://localhost:11434", "llama3", (*ollama.LLM)(nil), ""},
+		{"ollama", "", "", nil, "requires -model"},
+		{"gemini", "", "", nil, `unknown provider "gemini"`},
+		{"", "", "", nil, `unknown provider ""`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.provider+"/"+tt.model, func(t *testing.T) {
+			m, err := newModel(tt.provider, tt.baseURL, tt.model)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("newModel(%q, %q, %q) error = %v, want %q", tt.provider, tt.baseURL, tt.model, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("newModel(%q, %q, %q) error = %v", tt.provider, tt.baseURL, tt.model, err)
+			}
+			if got, want := reflect.TypeOf(m), reflect.TypeOf(tt.want); got != want {
+				t.Errorf("newModel(%q, %q, %q) = %v, want %v", tt.provider, tt.baseURL, tt.model, got, want)
+			}
+		})
+	}
+}
+
+func TestDefaultProvider(t *testing.T) {
+	t.Setenv("AUTO_FIX_GO_PROVIDER", "")
+	if got := envOr("AUTO_FIX_GO_PROVIDER", defaultProvider); got != "anthropic" {
+		t.Errorf("provider with AUTO_FIX_GO_PROVIDER unset = %q, want anthropic", got)
+	}
+	t.Setenv("AUTO_FIX_GO_PROVIDER", "ollama")
+	if got := envOr("AUTO_FIX_GO_PROVIDER", defaultProvider); got != "ollama" {
+		t.Errorf("provider with AUTO_FIX_GO_PROVIDER=ollama = %q, want ollama", got)
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// An attempt records one model-applied change and the test run after it.
+type attempt struct {
+	diff       string
+	passed     bool
+	testOutput string
+}
+
+func (a attempt) result() string {
+	if a.passed {
+		return "PASS"
+	}
+	return "FAIL"
+}
+
+func (a attempt) print(w io.Writer, n int) {
+	fmt.Fprintf(w, "--- Attempt %d: tests %s\n", n, a.result())
+	if a.diff == "" {
+		fmt.Fprintln(w, "(no changes)")
+	} else {
+		fmt.Fprint(w, a.diff)
+	}
+	if !a.passed {
+		fmt.Fprintf(w, "--- Test output after attempt %d\n%s", n, a.testOutput)
+		if !strings.HasSuffix(a.testOutput, "\n") {
+			fmt.Fprintln(w)
+		}
+	}
+}
+
+// A report summarizes a run for review.
+type report struct {
+	maxIterations int
+	attempts      []attempt
+	passed        bool
+	outcome       string
+}
+
+// write writes the report to w. If detailed is set, it includes the diff
+// and test output of every attempt.
+func (r *report) write(w io.Writer, detailed bool) {
+	fmt.Fprintln(w, "=== auto-fix-go report")
+	if len(r.attempts) == 0 && r.passed {
+		fmt.Fprintln(w, "Tests already pass; no changes made.")
+		return
+	}
+	for i, a := range r.attempts {
+		fmt.Fprintf(w, "Attempt %d: %s (%d lines changed)\n", i+1, a.result(), changedLines(a.diff))
+	}
+	switch {
+	case r.passed:
+		fmt.Fprintf(w, "Tests pass after %d of at most %d attempts.\n", len(r.attempts), r.maxIterations)
+	case r.outcome != "":
+		fmt.Fprintln(w, r.outcome)
+	}
+	if !detailed {
+		return
+	}
+	for i, a := range r.attempts {
+		fmt.Fprintln(w)
+		a.print(w, i+1)
+	}
+}
+
+// changedLines counts the added and removed lines in a unified diff.
+func changedLines(diff string) int {
+	n := 0
+	for _, line := range strings.Split(diff, "\n") {
+		if strings.HasPrefix(line, "+++ b/") || strings.HasPrefix(line, "--- a/") {
+			continue // file header
+		}
+		if strings.HasPrefix(line, "+") || strings.HasPrefix(line, "-") {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// runTests runs the tests of every package under dir. If tests is not
+// empty, only those top-level tests (with their subtests) are run.
+func runTests(dir string, tests []string) (bool, string) {
+	args := []string{"test"}
+	if len(tests) > 0 {
+		args = append(args, "-run", runPattern(tests))
+	}
+	args = append(args, "./...")
+	cmd := exec.Command("go", args...)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	return err == nil, string(output)
+}
+
+// runPattern returns a -run pattern matching exactly the named tests.
+func runPattern(tests []string) string {
+	quoted := make([]string, len(tests))
+	for i, t := range tests {
+		quoted[i] = regexp.QuoteMeta(t)
+	}
+	return "^(" + strings.Join(quoted, "|") + ")$"
+}
+
+// failLine matches the result line of a failed top-level test. Subtest
+// results are indented, so they do not match.
+var failLine = regexp.MustCompile(`^--- FAIL: (\S+) \(`)
+
+// failingTests returns the failed top-level tests reported in the output
+// of go test, in order of first appearance. It returns nil if any
+// package failed to build, since that package's tests never ran and
+// the whole suite has to be run again to find them.
+func failingTests(output string) []string {
+	var tests []string
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(output, "\n") {
+		if strings.HasSuffix(line, "[build failed]") || strings.HasSuffix(line, "[setup failed]") {
+			return nil
+		}
+		if m := failLine.FindStringSubmatch(line); m != nil && !seen[m[1]] {
+			seen[m[1]] = true
+			tests = append(tests, m[1])
+		}
+	}
+	return tests
+}
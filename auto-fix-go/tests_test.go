@@ -0,0 +1,40 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFailingTests(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   []string
+	}{
+		{
+			"failures",
+			"--- FAIL: TestA (0.00s)\n    a_test.go:5: bad\n--- FAIL: TestB (0.01s)\n    --- FAIL: TestB/sub (0.00s)\nFAIL\nFAIL\texample.com/p\t0.02s\n" +
+				"--- FAIL: TestA (0.00s)\nFAIL\nFAIL\texample.com/q\t0.01s\n",
+			[]string{"TestA", "TestB"},
+		},
+		{
+			"build failure",
+			"--- FAIL: TestA (0.00s)\nFAIL\nFAIL\texample.com/p\t0.02s\n# example.com/q\nq/q.go:3:1: syntax error\nFAIL\texample.com/q [build failed]\n",
+			nil,
+		},
+		{"pass", "ok  \texample.com/p\t0.01s\n", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := failingTests(tt.output); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("failingTests() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunPattern(t *testing.T) {
+	if got, want := runPattern([]string{"TestA", "TestB"}), "^(TestA|TestB)$"; got != want {
+		t.Errorf("runPattern() = %q, want %q", got, want)
+	}
+}
@@ -1,17 +1,102 @@
 package commands
 
 import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
 	"github.com/spf13/cobra"
+	"github.com/tmc/misc/autofix/rules"
 )
 
 // NewAnalyzeCommand creates a command for analyzing the codebase.
 func NewAnalyzeCommand() *cobra.Command {
-	return &cobra.Command{
-		Use:   "analyze",
+	var (
+		jsonOutput bool
+		ruleIDs    []string
+	)
+	cmd := &cobra.Command{
+		Use:   "analyze [path | packages]",
 		Short: "Analyze the codebase for potential improvements",
+		Long: `Analyze runs the built-in rules on the Go packages at path, a directory
+analyzed recursively, or matching the given package patterns. The
+default is the current directory. Each finding is printed as
+file:line:col: message [rule]. The command exits with an error if any
+findings are reported.
+
+Rules:
+` + ruleList(),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			// Implementation for code analysis
+			cmd.SilenceUsage = true
+			findings, err := analyze(args, ruleIDs)
+			if err != nil {
+				return err
+			}
+			if jsonOutput {
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "\t")
+				if findings == nil {
+					findings = []rules.Finding{}
+				}
+				if err := enc.Encode(findings); err != nil {
+					return err
+				}
+			} else {
+				for _, f := range findings {
+					fmt.Fprintln(cmd.OutOrStdout(), f)
+				}
+			}
+			if len(findings) > 0 {
+				return fmt.Errorf("%d issue(s) found", len(findings))
+			}
 			return nil
 		},
 	}
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "print findings as JSON")
+	cmd.Flags().StringSliceVar(&ruleIDs, "rules", nil, "comma-separated rules to run (default all)")
+	return cmd
+}
+
+// analyze runs the named rules, or all rules, on the packages given by
+// args, and returns the findings with file names relative to the
+// current directory where possible.
+func analyze(args, ruleIDs []string) ([]rules.Finding, error) {
+	rs, err := rules.Lookup(ruleIDs)
+	if err != nil {
+		return nil, err
+	}
+	dir, patterns := ".", args
+	if len(args) == 0 {
+		patterns = []string{"./..."}
+	} else if len(args) == 1 {
+		if fi, err := os.Stat(args[0]); err == nil && fi.IsDir() {
+			dir, patterns = args[0], []string{"./..."}
+		}
+	}
+	findings, err := rules.Analyze(dir, patterns, rs)
+	if err != nil {
+		return nil, err
+	}
+	if wd, err := os.Getwd(); err == nil {
+		for i, f := range findings {
+			if rel, err := filepath.Rel(wd, f.File); err == nil && !strings.HasPrefix(rel, "..") {
+				findings[i].File = rel
+			}
+		}
+	}
+	return findings, nil
+}
+
+func ruleList() string {
+	var sb strings.Builder
+	for _, r := range rules.All() {
+		fixable := ""
+		if r.Fixable {
+			fixable = " (fixable)"
+		}
+		fmt.Fprintf(&sb, "  %-16s %s%s\n", r.ID, r.Doc, fixable)
+	}
+	return sb.String()
 }
@@ -0,0 +1,144 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// setup copies the rules fixture module into the directory "example" of
+// a temporary directory and makes the latter the working directory. It
+// returns the path of the copy and of the fixture's golden file.
+func setup(t *testing.T) (dir, golden string) {
+	t.Helper()
+	src := filepath.Join("..", "rules", "testdata")
+	root := t.TempDir()
+	dir = filepath.Join(root, "example")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"go.mod", "example.go"} {
+		data, err := os.ReadFile(filepath.Join(src, "example", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	golden, err := filepath.Abs(filepath.Join(src, "example.go.golden"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(root); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	return dir, golden
+}
+
+// execute runs the autofix command line with args and returns its
+// output and error.
+func execute(args ...string) (string, error) {
+	var out bytes.Buffer
+	root := NewRoot()
+	root.SetOut(&out)
+	root.SetErr(&out)
+	root.SetArgs(args)
+	err := root.Execute()
+	return out.String(), err
+}
+
+func TestAnalyzeJSON(t *testing.T) {
+	setup(t)
+	out, err := execute("analyze", "--json", "example")
+	if err == nil || err.Error() != "6 issue(s) found" {
+		t.Errorf("analyze error = %v, want 6 issue(s) found", err)
+	}
+	var findings []map[string]any
+	if err := json.Unmarshal([]byte(out), &findings); err != nil {
+		t.Fatalf("analyze --json output is not JSON: %v\n%s", err, out)
+	}
+	if len(findings) != 6 {
+		t.Fatalf("got %d findings, want 6:\n%s", len(findings), out)
+	}
+	want := map[string]any{
+		"rule":    "errorf-wrap",
+		"file":    filepath.Join("example", "example.go"),
+		"line":    18.0,
+		"column":  44.0,
+		"message": "error err is formatted with %v; use %w to wrap it",
+		"fixable": true,
+	}
+	got := findings[2]
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("finding[2][%q] = %v, want %v", k, got[k], v)
+		}
+	}
+	if len(got) != len(want) {
+		t.Errorf("finding[2] has fields %v, want those of %v", got, want)
+	}
+}
+
+func TestAnalyzeRules(t *testing.T) {
+	dir, _ := setup(t)
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	out, err := execute("analyze", "--rules", "unchecked-error")
+	if err == nil {
+		t.Error("analyze succeeded despite an unchecked error")
+	}
+	if want := "example.go:12:2: error returned by Remove is not checked [unchecked-error]\n"; out != want {
+		t.Errorf("analyze output = %q, want %q", out, want)
+	}
+
+	if _, err := execute("analyze", "--rules", "no-such-rule"); err == nil || !strings.Contains(err.Error(), "unknown rule") {
+		t.Errorf("analyze with an unknown rule: error = %v", err)
+	}
+}
+
+func TestFix(t *testing.T) {
+	dir, goldenFile := setup(t)
+	out, err := execute("fix", "example")
+	if err == nil || err.Error() != "3 issue(s) need a manual fix" {
+		t.Errorf("fix error = %v, want 3 issue(s) need a manual fix", err)
+	}
+	for _, line := range []string{
+		"fixed " + filepath.Join("example", "example.go") + ":16:2: file f opened by Open is never closed [unclosed-file]",
+		filepath.Join("example", "example.go") + ":12:2: error returned by Remove is not checked [unchecked-error]",
+	} {
+		if !strings.Contains(out, line) {
+			t.Errorf("fix output missing %q:\n%s", line, out)
+		}
+	}
+
+	got, err := os.ReadFile(filepath.Join(dir, "example.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden, err := os.ReadFile(goldenFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(golden) {
+		t.Errorf("fixed source differs from golden file:\n%s", got)
+	}
+
+	// Nothing fixable is left, so a second run only reports the file
+	// opened in a loop, which has no fix.
+	out, err = execute("fix", "--rules", "errorf-wrap,unclosed-file", "example")
+	want := filepath.Join("example", "example.go") + ":95:3: file f opened by Open is never closed [unclosed-file]\n"
+	if err == nil || err.Error() != "1 issue(s) need a manual fix" || out != want {
+		t.Errorf("second fix = %q, %v; want %q, 1 issue(s) need a manual fix", out, err, want)
+	}
+}
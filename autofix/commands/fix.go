@@ -1,17 +1,53 @@
 package commands
 
 import (
+	"fmt"
+
 	"github.com/spf13/cobra"
+	"github.com/tmc/misc/autofix/rules"
 )
 
 // NewFixCommand creates a command for applying fixes to the codebase.
 func NewFixCommand() *cobra.Command {
-	return &cobra.Command{
-		Use:   "fix",
+	var ruleIDs []string
+	cmd := &cobra.Command{
+		Use:   "fix [path | packages]",
 		Short: "Automatically apply suggested fixes to the codebase",
+		Long: `Fix runs the same rules as analyze and rewrites the affected files to
+apply the fixes of the fixable findings. Findings without a fix are
+printed and must be addressed by hand; the command then exits with an
+error.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			// Implementation for applying fixes
+			cmd.SilenceUsage = true
+			findings, err := analyze(args, ruleIDs)
+			if err != nil {
+				return err
+			}
+			fixed, err := rules.Fix(findings)
+			for _, f := range fixed {
+				fmt.Fprintf(cmd.OutOrStdout(), "fixed %s\n", f)
+			}
+			if err != nil {
+				return err
+			}
+			manual, fixable := 0, 0
+			for _, f := range findings {
+				if f.Fixable {
+					fixable++
+				} else {
+					fmt.Fprintln(cmd.OutOrStdout(), f)
+					manual++
+				}
+			}
+			if manual > 0 {
+				return fmt.Errorf("%d issue(s) need a manual fix", manual)
+			}
+			if n := fixable - len(fixed); n > 0 {
+				return fmt.Errorf("%d fix(es) overlapped others and were not applied; run fix again", n)
+			}
 			return nil
 		},
 	}
+	cmd.Flags().StringSliceVar(&ruleIDs, "rules", nil, "comma-separated rules to apply (default all)")
+	return cmd
 }
@@ -19,6 +19,8 @@ func NewRoot() *cobra.Command {
 			return nil
 		},
 		Version: Version,
+		// main prints the error.
+		SilenceErrors: true,
 	}
 	// Add subcommands here
 	rootCmd.AddCommand(NewAnalyzeCommand())
@@ -0,0 +1,63 @@
+package rules
+
+import (
+	"fmt"
+	"go/ast"
+	"sort"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// Analyze loads the packages matching patterns, relative to dir, and
+// runs rules on each of their non-generated files. The findings are
+// sorted by position.
+func Analyze(dir string, patterns []string, rules []*Rule) ([]Finding, error) {
+	// Dependencies are type-checked from source, rather than loaded from
+	// export data, so that loading does not depend on the export data
+	// format of the installed toolchain.
+	cfg := &packages.Config{
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedImports | packages.NeedSyntax |
+			packages.NeedTypes | packages.NeedTypesInfo | packages.NeedDeps,
+		Dir: dir,
+	}
+	pkgs, err := packages.Load(cfg, patterns...)
+	if err != nil {
+		return nil, err
+	}
+	if n := packages.PrintErrors(pkgs); n > 0 {
+		return nil, fmt.Errorf("%d errors loading packages", n)
+	}
+
+	var findings []Finding
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Syntax {
+			if ast.IsGenerated(file) {
+				continue
+			}
+			for _, r := range rules {
+				r.Run(&Pass{
+					Fset:     pkg.Fset,
+					File:     file,
+					Pkg:      pkg.Types,
+					Info:     pkg.TypesInfo,
+					rule:     r,
+					findings: &findings,
+				})
+			}
+		}
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		if a.Column != b.Column {
+			return a.Column < b.Column
+		}
+		return a.Rule < b.Rule
+	})
+	return findings, nil
+}
@@ -0,0 +1,103 @@
+package rules
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+func init() {
+	Register(&Rule{
+		ID:      "errorf-wrap",
+		Doc:     "fmt.Errorf formats an error with %v or %s instead of wrapping it with %w",
+		Fixable: true,
+		Run:     runErrorfWrap,
+	})
+}
+
+// runErrorfWrap reports error arguments of fmt.Errorf formatted with a
+// plain %v or %s, which hides the error from errors.Is and errors.As.
+// The fix replaces the verb with %w. Format strings that are not
+// literals, or that use argument indexes or * widths, are skipped.
+func runErrorfWrap(pass *Pass) {
+	errorIface := types.Universe.Lookup("error").Type().Underlying().(*types.Interface)
+	ast.Inspect(pass.File, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || len(call.Args) < 2 || call.Ellipsis.IsValid() {
+			return true
+		}
+		fn, ok := typeutil.Callee(pass.Info, call).(*types.Func)
+		if !ok || fn.FullName() != "fmt.Errorf" {
+			return true
+		}
+		lit, ok := ast.Unparen(call.Args[0]).(*ast.BasicLit)
+		if !ok || lit.Kind != token.STRING {
+			return true
+		}
+		format := constant.StringVal(pass.Info.Types[lit].Value)
+		// Verb offsets are computed on the literal's source text, so
+		// it must spell each % directly rather than as an escape.
+		if strings.Count(lit.Value, "%") != strings.Count(format, "%") {
+			return true
+		}
+		verbs, ok := parseVerbs(lit.Value)
+		if !ok {
+			return true
+		}
+		for i, v := range verbs {
+			if i+1 >= len(call.Args) {
+				break
+			}
+			arg := call.Args[i+1]
+			t := pass.Info.TypeOf(arg)
+			if !v.plain || (v.verb != 'v' && v.verb != 's') || t == nil || !types.Implements(t, errorIface) {
+				continue
+			}
+			pos := lit.Pos() + token.Pos(v.offset)
+			msg := fmt.Sprintf("error %s is formatted with %%%c; use %%w to wrap it", types.ExprString(arg), v.verb)
+			pass.Report(arg.Pos(), msg, Edit{Pos: pos, End: pos + 1, New: "w"})
+		}
+		return true
+	})
+}
+
+// A verb is a formatting directive in a printf-style format.
+type verb struct {
+	verb   byte
+	offset int  // of the verb character
+	plain  bool // no flags, width, or precision
+}
+
+// parseVerbs returns the verbs of format in order, excluding %%. It
+// reports false if the format uses explicit argument indexes or *
+// widths, which make the mapping from verbs to arguments harder to
+// follow.
+func parseVerbs(format string) ([]verb, bool) {
+	var verbs []verb
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			continue
+		}
+		j := i + 1
+		for j < len(format) && strings.IndexByte("+-# 0123456789.", format[j]) >= 0 {
+			j++
+		}
+		if j == len(format) {
+			break
+		}
+		switch format[j] {
+		case '%':
+		case '[', '*':
+			return nil, false
+		default:
+			verbs = append(verbs, verb{verb: format[j], offset: j, plain: j == i+1})
+		}
+		i = j
+	}
+	return verbs, true
+}
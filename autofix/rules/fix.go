@@ -0,0 +1,80 @@
+package rules
+
+import (
+	"fmt"
+	"go/format"
+	"os"
+	"sort"
+)
+
+// Fix applies the edits of the fixable findings to their files, which
+// must not have changed since they were analyzed, and formats the
+// result with gofmt. A finding whose edits overlap those of an earlier
+// finding in the same file is skipped; running analyze again will
+// report it if it still applies. Fix returns the findings it fixed.
+func Fix(findings []Finding) ([]Finding, error) {
+	byFile := make(map[string][]Finding)
+	var names []string
+	for _, f := range findings {
+		if !f.Fixable {
+			continue
+		}
+		if byFile[f.File] == nil {
+			names = append(names, f.File)
+		}
+		byFile[f.File] = append(byFile[f.File], f)
+	}
+
+	var fixed []Finding
+	for _, name := range names {
+		src, err := os.ReadFile(name)
+		if err != nil {
+			return fixed, err
+		}
+		var edits []offsetEdit
+		var applied []Finding
+		for _, f := range byFile[name] {
+			if overlaps(edits, f.edits) {
+				continue
+			}
+			edits = append(edits, f.edits...)
+			applied = append(applied, f)
+		}
+		out, err := format.Source(applyEdits(src, edits))
+		if err != nil {
+			return fixed, fmt.Errorf("%s: fixed source does not parse: %v", name, err)
+		}
+		if err := os.WriteFile(name, out, 0o644); err != nil {
+			return fixed, err
+		}
+		fixed = append(fixed, applied...)
+	}
+	return fixed, nil
+}
+
+// overlaps reports whether any edit in b overlaps one in a. Two
+// insertions at the same offset also count as overlapping.
+func overlaps(a, b []offsetEdit) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if x.start < y.end && y.start < x.end || x.start == y.start {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// applyEdits returns src with the non-overlapping edits applied.
+func applyEdits(src []byte, edits []offsetEdit) []byte {
+	edits = append([]offsetEdit(nil), edits...)
+	sort.Slice(edits, func(i, j int) bool { return edits[i].start < edits[j].start })
+	var out []byte
+	last := 0
+	for _, e := range edits {
+		out = append(out, src[last:e.start]...)
+		out = append(out, e.new...)
+		last = e.end
+	}
+	return append(out, src[last:]...)
+}
@@ -0,0 +1,133 @@
+// Package rules implements the checks run by autofix analyze and the
+// fixes applied by autofix fix.
+//
+// Each check is a Rule. The built-in rules register themselves in this
+// package's init functions; other packages can add rules with Register
+// before calling Analyze.
+package rules
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+)
+
+// A Rule is a check run on every file of the analyzed packages.
+type Rule struct {
+	ID      string // short kebab-case name, e.g. "unchecked-error"
+	Doc     string // one-line description
+	Fixable bool   // whether the rule's findings carry fixes
+	Run     func(*Pass)
+}
+
+// A Pass provides a rule with one type-checked file and collects its
+// findings.
+type Pass struct {
+	Fset *token.FileSet
+	File *ast.File
+	Pkg  *types.Package
+	Info *types.Info
+
+	rule     *Rule
+	findings *[]Finding
+}
+
+// An Edit replaces the source between Pos and End with New. Pos == End
+// inserts New.
+type Edit struct {
+	Pos, End token.Pos
+	New      string
+}
+
+// Report records a finding at pos. The edits, if any, fix it.
+func (p *Pass) Report(pos token.Pos, msg string, edits ...Edit) {
+	posn := p.Fset.Position(pos)
+	f := Finding{
+		Rule:    p.rule.ID,
+		File:    posn.Filename,
+		Line:    posn.Line,
+		Column:  posn.Column,
+		Message: msg,
+		Fixable: len(edits) > 0,
+	}
+	for _, e := range edits {
+		f.edits = append(f.edits, offsetEdit{
+			start: p.Fset.Position(e.Pos).Offset,
+			end:   p.Fset.Position(e.End).Offset,
+			new:   e.New,
+		})
+	}
+	*p.findings = append(*p.findings, f)
+}
+
+// Reportf is like Report for a finding without a fix, with the message
+// formatted as by fmt.Sprintf.
+func (p *Pass) Reportf(pos token.Pos, format string, args ...any) {
+	p.Report(pos, fmt.Sprintf(format, args...))
+}
+
+// A Finding is an issue reported by a rule.
+type Finding struct {
+	Rule    string `json:"rule"`
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Message string `json:"message"`
+	Fixable bool   `json:"fixable"`
+
+	edits []offsetEdit
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s [%s]", f.File, f.Line, f.Column, f.Message, f.Rule)
+}
+
+// An offsetEdit is an Edit resolved to byte offsets in Finding.File.
+type offsetEdit struct {
+	start, end int
+	new        string
+}
+
+var registry = make(map[string]*Rule)
+
+// Register adds r to the rules known to All and Lookup. It panics if a
+// rule with the same ID is already registered.
+func Register(r *Rule) {
+	if r.ID == "" || r.Run == nil {
+		panic("rules: Register of incomplete rule")
+	}
+	if registry[r.ID] != nil {
+		panic("rules: duplicate rule " + r.ID)
+	}
+	registry[r.ID] = r
+}
+
+// All returns the registered rules, sorted by ID.
+func All() []*Rule {
+	all := make([]*Rule, 0, len(registry))
+	for _, r := range registry {
+		all = append(all, r)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].ID < all[j].ID })
+	return all
+}
+
+// Lookup returns the registered rules with the given IDs, or all of
+// them if ids is empty.
+func Lookup(ids []string) ([]*Rule, error) {
+	if len(ids) == 0 {
+		return All(), nil
+	}
+	var rules []*Rule
+	for _, id := range ids {
+		r := registry[strings.TrimSpace(id)]
+		if r == nil {
+			return nil, fmt.Errorf("unknown rule %q", id)
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
@@ -0,0 +1,99 @@
+package rules
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// summary returns "rule:line" for each finding.
+func summary(findings []Finding) []string {
+	var s []string
+	for _, f := range findings {
+		s = append(s, fmt.Sprintf("%s:%d", f.Rule, f.Line))
+	}
+	return s
+}
+
+func TestAnalyzeAndFix(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"go.mod", "example.go"} {
+		data, err := os.ReadFile(filepath.Join("testdata", "example", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	findings, err := Analyze(dir, []string{"./..."}, All())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"unchecked-error:12", "unclosed-file:16", "errorf-wrap:18", "shadowed-err:22", "shadowed-err:29", "unclosed-file:94"}
+	if got := summary(findings); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Analyze() = %q, want %q", got, want)
+	}
+
+	fixed, err := Fix(findings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []string{"unclosed-file:16", "errorf-wrap:18", "shadowed-err:29"}
+	if got := summary(fixed); !reflect.DeepEqual(got, want) {
+		t.Errorf("Fix() fixed %q, want %q", got, want)
+	}
+	got, err := os.ReadFile(filepath.Join(dir, "example.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden, err := os.ReadFile(filepath.Join("testdata", "example.go.golden"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(golden) {
+		t.Errorf("fixed source differs from golden file:\n%s", got)
+	}
+
+	// Only the findings without a fix remain.
+	findings, err = Analyze(dir, []string{"./..."}, All())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []string{"unchecked-error:12", "shadowed-err:23", "unclosed-file:95"}
+	if got := summary(findings); !reflect.DeepEqual(got, want) {
+		t.Errorf("Analyze() after Fix = %q, want %q", got, want)
+	}
+}
+
+func TestParseVerbs(t *testing.T) {
+	tests := []struct {
+		format string
+		want   []verb
+		ok     bool
+	}{
+		{"a %v b", []verb{{'v', 3, true}}, true},
+		{"%d%% %-5s", []verb{{'d', 1, true}, {'s', 8, false}}, true},
+		{"%[1]v", nil, false},
+		{"%*d", nil, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseVerbs(tt.format)
+		if !reflect.DeepEqual(got, tt.want) || ok != tt.ok {
+			t.Errorf("parseVerbs(%q) = %v, %v, want %v, %v", tt.format, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+	rules, err := Lookup([]string{"errorf-wrap"})
+	if err != nil || len(rules) != 1 || rules[0].ID != "errorf-wrap" {
+		t.Errorf("Lookup(errorf-wrap) = %v, %v", rules, err)
+	}
+	if _, err := Lookup([]string{"no-such-rule"}); err == nil {
+		t.Error("Lookup(no-such-rule) succeeded")
+	}
+}
@@ -0,0 +1,118 @@
+package rules
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+)
+
+func init() {
+	Register(&Rule{
+		ID:      "shadowed-err",
+		Doc:     "err declared with := hides an err of the enclosing function that is used later",
+		Fixable: true,
+		Run:     runShadowedErr,
+	})
+}
+
+// runShadowedErr reports a := that declares a new err in an inner block
+// of a function that already has an err, when the outer err is used
+// after the block ends: a value assigned to the inner err is then
+// silently lost. Assigning to the outer err does not count as a use, and
+// an err declared in the init clause of an if, switch, or for statement
+// and checked there is not reported, since its value never needs to
+// leave that statement. Only shadowing within one function is
+// considered, and package-level variables are ignored.
+//
+// If err is the only variable the statement declares, the fix turns the
+// := into =, so the statement assigns the outer err instead.
+func runShadowedErr(pass *Pass) {
+	// The scope of each function, the statement whose init clause opens
+	// each scope, and the last read of each variable.
+	funcScopes := make(map[*types.Scope]bool)
+	initScopes := make(map[*types.Scope]ast.Stmt)
+	for node, scope := range pass.Info.Scopes {
+		switch node := node.(type) {
+		case *ast.FuncType:
+			funcScopes[scope] = true
+		case *ast.IfStmt:
+			initScopes[scope] = node.Init
+		case *ast.SwitchStmt:
+			initScopes[scope] = node.Init
+		case *ast.TypeSwitchStmt:
+			initScopes[scope] = node.Init
+		case *ast.ForStmt:
+			initScopes[scope] = node.Init
+		}
+	}
+	written := make(map[*ast.Ident]bool)
+	ast.Inspect(pass.File, func(n ast.Node) bool {
+		if assign, ok := n.(*ast.AssignStmt); ok && assign.Tok == token.ASSIGN {
+			for _, lhs := range assign.Lhs {
+				if id, ok := ast.Unparen(lhs).(*ast.Ident); ok {
+					written[id] = true
+				}
+			}
+		}
+		return true
+	})
+	lastUse := make(map[types.Object]token.Pos)
+	for id, obj := range pass.Info.Uses {
+		if !written[id] && id.Pos() > lastUse[obj] {
+			lastUse[obj] = id.Pos()
+		}
+	}
+	funcOf := func(s *types.Scope) *types.Scope {
+		for ; s != nil; s = s.Parent() {
+			if funcScopes[s] {
+				return s
+			}
+		}
+		return nil
+	}
+
+	ast.Inspect(pass.File, func(n ast.Node) bool {
+		assign, ok := n.(*ast.AssignStmt)
+		if !ok || assign.Tok != token.DEFINE {
+			return true
+		}
+		var inner *types.Var
+		declared := 0
+		for _, lhs := range assign.Lhs {
+			id, ok := lhs.(*ast.Ident)
+			if !ok {
+				continue
+			}
+			if obj, ok := pass.Info.Defs[id].(*types.Var); ok {
+				declared++
+				if id.Name == "err" {
+					inner = obj
+				}
+			}
+		}
+		if inner == nil || inner.Parent() == nil {
+			return true
+		}
+		fn := funcOf(inner.Parent())
+		if fn == nil || inner.Parent() == fn {
+			return true
+		}
+		if init, ok := initScopes[inner.Parent()]; ok && init == assign && lastUse[inner].IsValid() {
+			return true // checked within the statement it is declared by
+		}
+		_, prev := inner.Parent().Parent().LookupParent("err", inner.Pos())
+		outer, ok := prev.(*types.Var)
+		if !ok || funcOf(outer.Parent()) != fn || lastUse[outer] <= inner.Parent().End() {
+			return true
+		}
+
+		msg := fmt.Sprintf("declaration of err shadows declaration at line %d", pass.Fset.Position(outer.Pos()).Line)
+		if declared == 1 && types.Identical(inner.Type(), outer.Type()) {
+			pass.Report(inner.Pos(), msg, Edit{Pos: assign.TokPos, End: assign.TokPos + token.Pos(len(":=")), New: "="})
+		} else {
+			pass.Report(inner.Pos(), msg)
+		}
+		return true
+	})
+}
@@ -0,0 +1,102 @@
+// Package example has one instance of each issue found by the rules.
+package example
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+func remove(name string) {
+	os.Remove(name) // unchecked-error
+}
+
+func firstLine(name string) (string, error) {
+	f, err := os.Open(name) // unclosed-file
+	if err != nil {
+		return "", fmt.Errorf("open config: %v", err) // errorf-wrap
+	}
+	line, err := bufio.NewReader(f).ReadString('\n')
+	if strings.HasSuffix(line, "\r\n") {
+		n, err := fmt.Sscan(line) // shadowed-err, not fixable: n is new too
+		if err != nil {
+			return "", err
+		}
+		_ = n
+	}
+	if line == "" {
+		err := fmt.Errorf("empty file") // shadowed-err
+		_ = err
+	}
+	return line, err
+}
+
+// Closed, returned, and passed files are not reported.
+func closed(name string) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	var sb strings.Builder
+	sb.WriteString("ok") // allowed
+	_, err = f.WriteString(sb.String())
+	return err
+}
+
+func returned(name string) (*os.File, error) {
+	f, err := os.Open(name)
+	return f, err
+}
+
+func passed(name string) {
+	f, _ := os.Open(name)
+	use(f)
+}
+
+func use(*os.File) {}
+
+// An err declared and checked in an if statement is not reported, even
+// though the outer err is used later.
+func checked(name string) error {
+	err := os.Remove(name)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(name + ".bak"); err != nil {
+		return fmt.Errorf("remove backup: %w", err)
+	}
+	err = os.Remove(name + ".old")
+	return err
+}
+
+// Assigning the outer err after the block is not a use of it.
+func overwritten(name string) {
+	err := os.Remove(name)
+	if err != nil {
+		return
+	}
+	if name != "" {
+		err := os.Remove(name + ".tmp")
+		if err != nil {
+			return
+		}
+	}
+	err = os.Remove(name + ".old")
+}
+
+// A file opened in a loop is reported without a fix: a deferred Close
+// would run only when the function returns.
+func sizes(names []string) []int64 {
+	var sizes []int64
+	for _, name := range names {
+		f, err := os.Open(name) // unclosed-file, not fixable
+		if err != nil {
+			return sizes
+		}
+		fi, _ := f.Stat()
+		sizes = append(sizes, fi.Size())
+	}
+	return sizes
+}
@@ -0,0 +1,71 @@
+package rules
+
+import (
+	"go/ast"
+	"go/types"
+
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+func init() {
+	Register(&Rule{
+		ID:  "unchecked-error",
+		Doc: "call statement discards a returned error",
+		Run: runUncheckedError,
+	})
+}
+
+// uncheckedAllowed are functions whose error result is conventionally
+// ignored.
+var uncheckedAllowed = map[string]bool{
+	"fmt.Print":                      true,
+	"fmt.Printf":                     true,
+	"fmt.Println":                    true,
+	"fmt.Fprint":                     true,
+	"fmt.Fprintf":                    true,
+	"fmt.Fprintln":                   true,
+	"(*bytes.Buffer).Write":          true,
+	"(*bytes.Buffer).WriteByte":      true,
+	"(*bytes.Buffer).WriteRune":      true,
+	"(*bytes.Buffer).WriteString":    true,
+	"(*strings.Builder).Write":       true,
+	"(*strings.Builder).WriteByte":   true,
+	"(*strings.Builder).WriteRune":   true,
+	"(*strings.Builder).WriteString": true,
+	"(*text/tabwriter.Writer).Flush": true,
+	"(*math/rand.Rand).Read":         true,
+	"(hash.Hash).Write":              true,
+}
+
+// runUncheckedError reports expression statements whose call returns an
+// error, as its only or last result, that is dropped. Deferred and go
+// calls are not reported.
+func runUncheckedError(pass *Pass) {
+	errorType := types.Universe.Lookup("error").Type()
+	ast.Inspect(pass.File, func(n ast.Node) bool {
+		stmt, ok := n.(*ast.ExprStmt)
+		if !ok {
+			return true
+		}
+		call, ok := ast.Unparen(stmt.X).(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		t := pass.Info.TypeOf(call)
+		if tuple, ok := t.(*types.Tuple); ok && tuple.Len() > 0 {
+			t = tuple.At(tuple.Len() - 1).Type()
+		}
+		if t == nil || !types.Identical(t, errorType) {
+			return true
+		}
+		name := types.ExprString(call.Fun)
+		if fn, ok := typeutil.Callee(pass.Info, call).(*types.Func); ok {
+			if uncheckedAllowed[fn.Origin().FullName()] {
+				return true
+			}
+			name = fn.Name()
+		}
+		pass.Reportf(call.Pos(), "error returned by %s is not checked", name)
+		return true
+	})
+}
@@ -0,0 +1,225 @@
+package rules
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+func init() {
+	Register(&Rule{
+		ID:      "unclosed-file",
+		Doc:     "file opened in a function is never closed or handed off",
+		Fixable: true,
+		Run:     runUnclosedFile,
+	})
+}
+
+// openers are the functions whose first result must be closed.
+var openers = map[string]bool{
+	"os.Open":       true,
+	"os.Create":     true,
+	"os.OpenFile":   true,
+	"os.CreateTemp": true,
+}
+
+// runUnclosedFile reports a file opened into a local variable when the
+// function never calls its Close method and the file does not escape:
+// it is not returned, stored, captured by a closure, or passed to a
+// parameter that has a Close method. Files that escape are closed by
+// someone else, if at all.
+//
+// When the open is directly followed by an "if err != nil" block, or
+// its error is discarded, the fix inserts "defer f.Close()" after it.
+// Opens inside a loop get no fix, since a deferred Close would keep each
+// iteration's file open until the function returns.
+func runUnclosedFile(pass *Pass) {
+	for _, decl := range pass.File.Decls {
+		fd, ok := decl.(*ast.FuncDecl)
+		if ok && fd.Body != nil {
+			checkUnclosed(pass, fd.Body)
+		}
+	}
+}
+
+func checkUnclosed(pass *Pass, body *ast.BlockStmt) {
+	loops := 0 // enclosing for and range statements
+	var stack []ast.Node
+	ast.Inspect(body, func(n ast.Node) bool {
+		if n == nil {
+			switch stack[len(stack)-1].(type) {
+			case *ast.ForStmt, *ast.RangeStmt:
+				loops--
+			}
+			stack = stack[:len(stack)-1]
+			return true
+		}
+		if lit, ok := n.(*ast.FuncLit); ok {
+			checkUnclosed(pass, lit.Body)
+			return false
+		}
+		stack = append(stack, n)
+		switch n.(type) {
+		case *ast.ForStmt, *ast.RangeStmt:
+			loops++
+		}
+		block, ok := n.(*ast.BlockStmt)
+		if !ok {
+			return true
+		}
+		for i, stmt := range block.List {
+			assign, ok := stmt.(*ast.AssignStmt)
+			if !ok || len(assign.Rhs) != 1 || len(assign.Lhs) == 0 {
+				continue
+			}
+			call, ok := assign.Rhs[0].(*ast.CallExpr)
+			if !ok {
+				continue
+			}
+			fn, ok := typeutil.Callee(pass.Info, call).(*types.Func)
+			if !ok || !openers[fn.FullName()] {
+				continue
+			}
+			id, ok := assign.Lhs[0].(*ast.Ident)
+			if !ok || id.Name == "_" {
+				continue
+			}
+			file, ok := pass.Info.ObjectOf(id).(*types.Var)
+			if !ok || file.Parent() == nil || file.Parent() == pass.Pkg.Scope() || handled(pass.Info, body, file) {
+				continue
+			}
+
+			msg := fmt.Sprintf("file %s opened by %s is never closed", id.Name, fn.Name())
+			at := token.NoPos
+			switch {
+			case loops > 0:
+			case len(assign.Lhs) == 2 && isBlank(assign.Lhs[1]):
+				at = assign.End()
+			case len(assign.Lhs) == 2 && i+1 < len(block.List) && isErrCheck(block.List[i+1], assign.Lhs[1]):
+				at = block.List[i+1].End()
+			}
+			if at.IsValid() {
+				pass.Report(assign.Pos(), msg, Edit{Pos: at, End: at, New: "\ndefer " + id.Name + ".Close()"})
+			} else {
+				pass.Report(assign.Pos(), msg)
+			}
+		}
+		return true
+	})
+}
+
+// handled reports whether v, within body, has its Close method called
+// or escapes to code that may close it.
+func handled(info *types.Info, body *ast.BlockStmt, v *types.Var) bool {
+	found := false
+	var stack []ast.Node
+	ast.Inspect(body, func(n ast.Node) bool {
+		if found {
+			return false
+		}
+		if n == nil {
+			stack = stack[:len(stack)-1]
+			return true
+		}
+		stack = append(stack, n)
+		id, ok := n.(*ast.Ident)
+		if !ok || info.Uses[id] != v {
+			return true
+		}
+		parent := stack[len(stack)-2]
+		switch parent := parent.(type) {
+		case *ast.SelectorExpr:
+			// f.Close(), or any method value: only Close counts, and
+			// other methods like f.Write keep the file local.
+			if parent.Sel.Name == "Close" {
+				found = true
+			}
+		case *ast.CallExpr:
+			found = parent.Fun != n && mayClose(info, parent, n)
+		case *ast.ReturnStmt, *ast.CompositeLit, *ast.KeyValueExpr, *ast.SendStmt, *ast.UnaryExpr:
+			found = true
+		case *ast.AssignStmt:
+			for _, rhs := range parent.Rhs {
+				if rhs == n {
+					found = true
+				}
+			}
+		case *ast.ValueSpec:
+			found = true
+		}
+		for _, s := range stack[:len(stack)-1] {
+			if _, ok := s.(*ast.FuncLit); ok {
+				found = true // captured by a closure
+			}
+		}
+		return true
+	})
+	return found
+}
+
+// mayClose reports whether the callee of call may close its argument
+// arg: that is, the parameter's type has a Close method. Passing a file
+// as an io.Reader, for example, keeps the caller responsible for it.
+func mayClose(info *types.Info, call *ast.CallExpr, arg ast.Node) bool {
+	sig, ok := info.TypeOf(call.Fun).(*types.Signature)
+	if !ok {
+		return true // conversion
+	}
+	params := sig.Params()
+	for i, a := range call.Args {
+		if a != arg {
+			continue
+		}
+		var t types.Type
+		switch {
+		case sig.Variadic() && i >= params.Len()-1:
+			t = params.At(params.Len() - 1).Type()
+			if !call.Ellipsis.IsValid() {
+				t = t.(*types.Slice).Elem()
+			}
+		case i < params.Len():
+			t = params.At(i).Type()
+		default:
+			return true
+		}
+		obj, _, _ := types.LookupFieldOrMethod(t, true, nil, "Close")
+		return obj != nil
+	}
+	return true
+}
+
+func isBlank(e ast.Expr) bool {
+	id, ok := e.(*ast.Ident)
+	return ok && id.Name == "_"
+}
+
+// isErrCheck reports whether stmt is "if err != nil { ... }", with err
+// the given expression, and the block ends in a return.
+func isErrCheck(stmt ast.Stmt, err ast.Expr) bool {
+	ifStmt, ok := stmt.(*ast.IfStmt)
+	if !ok || ifStmt.Init != nil || ifStmt.Else != nil {
+		return false
+	}
+	errID, ok := err.(*ast.Ident)
+	if !ok {
+		return false
+	}
+	cond, ok := ifStmt.Cond.(*ast.BinaryExpr)
+	if !ok || cond.Op != token.NEQ {
+		return false
+	}
+	x, ok := cond.X.(*ast.Ident)
+	y, ok2 := cond.Y.(*ast.Ident)
+	if !ok || !ok2 || x.Name != errID.Name || y.Name != "nil" {
+		return false
+	}
+	list := ifStmt.Body.List
+	if len(list) == 0 {
+		return false
+	}
+	_, ok = list[len(list)-1].(*ast.ReturnStmt)
+	return ok
+}
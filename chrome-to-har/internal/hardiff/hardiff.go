@@ -0,0 +1,275 @@
+// Package hardiff compares two HAR captures and reports request-level
+// differences between them.
+package hardiff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/chromedp/cdproto/har"
+	"github.com/pkg/errors"
+)
+
+// QueryMode controls how query strings are treated when matching requests.
+type QueryMode string
+
+const (
+	QueryKeep   QueryMode = "keep"   // match the query string as-is
+	QuerySort   QueryMode = "sort"   // match regardless of parameter order
+	QueryIgnore QueryMode = "ignore" // ignore the query string entirely
+)
+
+// ParseQueryMode parses a QueryMode from its flag value.
+func ParseQueryMode(s string) (QueryMode, error) {
+	switch m := QueryMode(s); m {
+	case QueryKeep, QuerySort, QueryIgnore:
+		return m, nil
+	}
+	return "", errors.Errorf("invalid query mode %q (want keep, sort, or ignore)", s)
+}
+
+// Options configures a comparison.
+type Options struct {
+	Query QueryMode
+	// Threshold is the minimum increase in average request time that
+	// counts as a timing regression.
+	Threshold time.Duration
+}
+
+// Kinds of Change.
+const (
+	Added   = "added"
+	Removed = "removed"
+	Status  = "status"
+	Timing  = "timing"
+	Count   = "count"
+)
+
+var kindOrder = map[string]int{Added: 0, Removed: 1, Status: 2, Timing: 3, Count: 4}
+
+// Stats summarizes the requests matching one method and URL in a HAR.
+type Stats struct {
+	Count    int     `json:"count"`
+	Statuses []int64 `json:"statuses"`
+	AvgTime  float64 `json:"avgTimeMs"`
+}
+
+// Change is a single difference between two captures.
+type Change struct {
+	Kind   string `json:"kind"`
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	Before *Stats `json:"before,omitempty"`
+	After  *Stats `json:"after,omitempty"`
+}
+
+// Result holds all changes found by Compare, ordered by kind and URL.
+type Result struct {
+	Changes []Change `json:"changes"`
+}
+
+// Load reads a HAR file.
+func Load(filename string) (*har.HAR, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading HAR file")
+	}
+	var h har.HAR
+	if err := json.Unmarshal(data, &h); err != nil {
+		return nil, errors.Wrapf(err, "parsing %s", filename)
+	}
+	if h.Log == nil {
+		return nil, errors.Errorf("%s: missing log", filename)
+	}
+	return &h, nil
+}
+
+type key struct {
+	method, url string
+}
+
+// Compare reports the differences between captures a and b.
+func Compare(a, b *har.HAR, opts Options) (*Result, error) {
+	if opts.Query == "" {
+		opts.Query = QuerySort
+	}
+	before, err := summarize(a, opts.Query)
+	if err != nil {
+		return nil, err
+	}
+	after, err := summarize(b, opts.Query)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &Result{Changes: make([]Change, 0)}
+	add := func(kind string, k key, before, after *Stats) {
+		res.Changes = append(res.Changes, Change{
+			Kind:   kind,
+			Method: k.method,
+			URL:    k.url,
+			Before: before,
+			After:  after,
+		})
+	}
+
+	threshold := float64(opts.Threshold) / float64(time.Millisecond)
+	for k, sb := range before {
+		sa, ok := after[k]
+		if !ok {
+			add(Removed, k, sb, nil)
+			continue
+		}
+		if !equalStatuses(sb.Statuses, sa.Statuses) {
+			add(Status, k, sb, sa)
+		}
+		if sa.AvgTime-sb.AvgTime > threshold {
+			add(Timing, k, sb, sa)
+		}
+		if sa.Count != sb.Count {
+			add(Count, k, sb, sa)
+		}
+	}
+	for k, sa := range after {
+		if _, ok := before[k]; !ok {
+			add(Added, k, nil, sa)
+		}
+	}
+
+	sort.Slice(res.Changes, func(i, j int) bool {
+		ci, cj := res.Changes[i], res.Changes[j]
+		if ci.Kind != cj.Kind {
+			return kindOrder[ci.Kind] < kindOrder[cj.Kind]
+		}
+		if ci.URL != cj.URL {
+			return ci.URL < cj.URL
+		}
+		return ci.Method < cj.Method
+	})
+	return res, nil
+}
+
+func summarize(h *har.HAR, mode QueryMode) (map[key]*Stats, error) {
+	stats := make(map[key]*Stats)
+	totals := make(map[key]float64)
+	for _, e := range h.Log.Entries {
+		if e.Request == nil {
+			continue
+		}
+		u, err := NormalizeURL(e.Request.URL, mode)
+		if err != nil {
+			return nil, err
+		}
+		k := key{method: e.Request.Method, url: u}
+		s := stats[k]
+		if s == nil {
+			s = &Stats{}
+			stats[k] = s
+		}
+		s.Count++
+		totals[k] += e.Time
+		if e.Response != nil {
+			s.Statuses = append(s.Statuses, e.Response.Status)
+		}
+	}
+	for k, s := range stats {
+		s.AvgTime = totals[k] / float64(s.Count)
+		sort.Slice(s.Statuses, func(i, j int) bool { return s.Statuses[i] < s.Statuses[j] })
+		s.Statuses = dedupe(s.Statuses)
+	}
+	return stats, nil
+}
+
+// NormalizeURL returns rawURL with its fragment removed and its query
+// string rewritten according to mode.
+func NormalizeURL(rawURL string, mode QueryMode) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", errors.Wrapf(err, "parsing URL %q", rawURL)
+	}
+	u.Fragment = ""
+	switch mode {
+	case QueryIgnore:
+		u.RawQuery = ""
+	case QuerySort:
+		u.RawQuery = u.Query().Encode()
+	}
+	return u.String(), nil
+}
+
+func dedupe(s []int64) []int64 {
+	out := s[:0]
+	for i, v := range s {
+		if i == 0 || v != s[i-1] {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func equalStatuses(a, b []int64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// WriteText writes a human-readable report of res to w.
+func (res *Result) WriteText(w io.Writer) error {
+	counts := make(map[string]int)
+	for _, c := range res.Changes {
+		counts[c.Kind]++
+		var err error
+		switch c.Kind {
+		case Added:
+			_, err = fmt.Fprintf(w, "+ %s %s (%s)\n", c.Method, c.URL, plural(c.After.Count, "request"))
+		case Removed:
+			_, err = fmt.Fprintf(w, "- %s %s (%s)\n", c.Method, c.URL, plural(c.Before.Count, "request"))
+		case Status:
+			_, err = fmt.Fprintf(w, "~ %s %s status %s -> %s\n", c.Method, c.URL, formatStatuses(c.Before.Statuses), formatStatuses(c.After.Statuses))
+		case Timing:
+			_, err = fmt.Fprintf(w, "! %s %s time %.0fms -> %.0fms (+%.0fms)\n", c.Method, c.URL, c.Before.AvgTime, c.After.AvgTime, c.After.AvgTime-c.Before.AvgTime)
+		case Count:
+			_, err = fmt.Fprintf(w, "# %s %s count %d -> %d\n", c.Method, c.URL, c.Before.Count, c.After.Count)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "\n%d added, %d removed, %d status changes, %d timing regressions, %d count changes\n",
+		counts[Added], counts[Removed], counts[Status], counts[Timing], counts[Count])
+	return err
+}
+
+// WriteJSON writes res to w as indented JSON.
+func (res *Result) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(res)
+}
+
+func formatStatuses(statuses []int64) string {
+	parts := make([]string, len(statuses))
+	for i, s := range statuses {
+		parts[i] = fmt.Sprint(s)
+	}
+	return strings.Join(parts, ",")
+}
+
+func plural(n int, word string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, word)
+	}
+	return fmt.Sprintf("%d %ss", n, word)
+}
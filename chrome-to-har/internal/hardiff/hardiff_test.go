@@ -0,0 +1,185 @@
+package hardiff
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/har"
+	"github.com/chromedp/cdproto/network"
+	"github.com/tmc/misc/chrome-to-har/internal/recorder"
+)
+
+func entry(method, url string, status int64, ms float64) *har.Entry {
+	return &har.Entry{
+		Time:     ms,
+		Request:  &har.Request{Method: method, URL: url},
+		Response: &har.Response{Status: status},
+	}
+}
+
+func capture(entries ...*har.Entry) *har.HAR {
+	return &har.HAR{Log: &har.Log{Entries: entries}}
+}
+
+func TestCompare(t *testing.T) {
+	a := capture(
+		entry("GET", "https://example.com/", 200, 100),
+		entry("GET", "https://example.com/api?b=2&a=1", 200, 50),
+		entry("GET", "https://example.com/old.js", 200, 10),
+		entry("POST", "https://example.com/track", 204, 5),
+	)
+	b := capture(
+		entry("GET", "https://example.com/", 200, 400),
+		entry("GET", "https://example.com/api?a=1&b=2", 500, 60),
+		entry("GET", "https://example.com/new.js", 200, 10),
+		entry("POST", "https://example.com/track", 204, 5),
+		entry("POST", "https://example.com/track", 204, 5),
+	)
+
+	res, err := Compare(a, b, Options{Query: QuerySort, Threshold: 100 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, c := range res.Changes {
+		got = append(got, c.Kind+" "+c.Method+" "+c.URL)
+	}
+	want := []string{
+		"added GET https://example.com/new.js",
+		"removed GET https://example.com/old.js",
+		"status GET https://example.com/api?a=1&b=2",
+		"timing GET https://example.com/",
+		"count POST https://example.com/track",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Compare() changes:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	var text bytes.Buffer
+	if err := res.WriteText(&text); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		"+ GET https://example.com/new.js (1 request)",
+		"~ GET https://example.com/api?a=1&b=2 status 200 -> 500",
+		"! GET https://example.com/ time 100ms -> 400ms (+300ms)",
+		"1 added, 1 removed, 1 status changes, 1 timing regressions, 1 count changes",
+	} {
+		if !strings.Contains(text.String(), line) {
+			t.Errorf("WriteText() output missing %q:\n%s", line, text.String())
+		}
+	}
+
+	var out bytes.Buffer
+	if err := res.WriteJSON(&out); err != nil {
+		t.Fatal(err)
+	}
+	var decoded Result
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+		t.Fatalf("WriteJSON() produced invalid JSON: %v", err)
+	}
+	if len(decoded.Changes) != len(want) {
+		t.Errorf("WriteJSON() decoded %d changes, want %d", len(decoded.Changes), len(want))
+	}
+}
+
+// record writes a HAR produced by the recorder for requests to each URL
+// in durations, taking the given number of milliseconds. The browser's
+// monotonic clock starts at base.
+func record(t *testing.T, base time.Time, durations map[string]float64) string {
+	t.Helper()
+	rec, err := recorder.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	handle := rec.HandleNetworkEvent(context.Background())
+	at := func(ms float64) *cdp.MonotonicTime {
+		ts := cdp.MonotonicTime(base.Add(time.Duration(ms * float64(time.Millisecond))))
+		return &ts
+	}
+	i := 0
+	for url, ms := range durations {
+		id := network.RequestID(url)
+		start := float64(i * 1000)
+		i++
+		handle(&network.EventRequestWillBeSent{
+			RequestID: id,
+			Request:   &network.Request{Method: "GET", URL: url},
+			Timestamp: at(start),
+		})
+		handle(&network.EventResponseReceived{
+			RequestID: id,
+			Response:  &network.Response{URL: url, Status: 200},
+		})
+		handle(&network.EventLoadingFinished{RequestID: id, Timestamp: at(start + ms)})
+	}
+	name := filepath.Join(t.TempDir(), "capture.har")
+	if err := rec.WriteHAR(name); err != nil {
+		t.Fatal(err)
+	}
+	return name
+}
+
+func TestCompareRecordedHARs(t *testing.T) {
+	// The second capture runs an hour later; only /slow got slower.
+	base := time.Now()
+	a, err := Load(record(t, base, map[string]float64{
+		"https://example.com/":     120,
+		"https://example.com/slow": 100,
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Load(record(t, base.Add(time.Hour), map[string]float64{
+		"https://example.com/":     130,
+		"https://example.com/slow": 900,
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range a.Log.Entries {
+		if e.Request.URL == "https://example.com/" && e.Time != 120 {
+			t.Errorf("recorded entry time = %vms, want 120ms", e.Time)
+		}
+	}
+
+	res, err := Compare(a, b, Options{Threshold: 100 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, c := range res.Changes {
+		got = append(got, c.Kind+" "+c.Method+" "+c.URL)
+	}
+	if want := "timing GET https://example.com/slow"; strings.Join(got, "\n") != want {
+		t.Errorf("Compare() changes = %q, want %q", got, want)
+	}
+}
+
+func TestNormalizeURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		mode QueryMode
+		want string
+	}{
+		{"https://example.com/a?b=2&a=1#top", QueryKeep, "https://example.com/a?b=2&a=1"},
+		{"https://example.com/a?b=2&a=1", QuerySort, "https://example.com/a?a=1&b=2"},
+		{"https://example.com/a?b=2&a=1", QueryIgnore, "https://example.com/a"},
+	}
+	for _, tt := range tests {
+		got, err := NormalizeURL(tt.url, tt.mode)
+		if err != nil {
+			t.Fatalf("NormalizeURL(%q, %q) error = %v", tt.url, tt.mode, err)
+		}
+		if got != tt.want {
+			t.Errorf("NormalizeURL(%q, %q) = %q, want %q", tt.url, tt.mode, got, tt.want)
+		}
+	}
+}
@@ -2,30 +2,65 @@ package recorder
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"regexp"
 	"strings"
 	"sync"
 	"text/template"
 	"time"
+	"unicode/utf8"
 
 	"github.com/chromedp/cdproto/har"
 	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/page"
 	"github.com/pkg/errors"
 )
 
 type Recorder struct {
 	sync.Mutex
-	requests  map[network.RequestID]*network.Request
-	responses map[network.RequestID]*network.Response
-	bodies    map[network.RequestID][]byte
-	timings   map[network.RequestID]*network.EventLoadingFinished
-	verbose   bool
-	streaming bool
-	filter    *FilterOption
-	template  string
+	requests       map[network.RequestID]*network.Request
+	responses      map[network.RequestID]*network.Response
+	bodies         map[network.RequestID][]byte
+	timings        map[network.RequestID]*network.EventLoadingFinished
+	verbose        bool
+	streaming      bool
+	filter         *FilterOption
+	template       string
+	urlPattern     *regexp.Regexp
+	omitPattern    *regexp.Regexp
+	captureContent bool
+	maxBodySize    int64
+	output         io.Writer
+
+	// order records requests in the order they were sent, and started
+	// their start times, so HAR entries are written chronologically.
+	// sentAt holds the browser's monotonic timestamp of each request's
+	// first requestWillBeSent event, from which entry times are measured.
+	order   []network.RequestID
+	started map[network.RequestID]time.Time
+	sentAt  map[network.RequestID]time.Time
+
+	// pages holds one HAR page per StartPage call; pageRefs maps each
+	// request to the page that was current when it was sent.
+	pages     []*har.Page
+	pageStart time.Time
+	pageRefs  map[network.RequestID]string
+
+	// inflight and lastActivity track network activity for WaitForNetworkIdle.
+	inflight     map[network.RequestID]bool
+	lastActivity time.Time
+
+	// pending tracks in-flight response body fetches. Once drained is
+	// set, by Drain, no new fetches are started.
+	pending sync.WaitGroup
+	drained bool
+	// fetchBody retrieves a response body; it is replaced in tests.
+	fetchBody func(ctx context.Context, id network.RequestID) ([]byte, error)
 }
 
 type FilterOption struct {
@@ -65,12 +100,76 @@ func WithTemplate(template string) Option {
 	}
 }
 
+// WithURLPattern limits captured requests to URLs matching pattern.
+func WithURLPattern(pattern string) Option {
+	return func(r *Recorder) error {
+		if pattern == "" {
+			return nil
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return errors.Wrap(err, "compiling URL pattern")
+		}
+		r.urlPattern = re
+		return nil
+	}
+}
+
+// WithOmitPattern excludes requests with URLs matching pattern.
+func WithOmitPattern(pattern string) Option {
+	return func(r *Recorder) error {
+		if pattern == "" {
+			return nil
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return errors.Wrap(err, "compiling omit pattern")
+		}
+		r.omitPattern = re
+		return nil
+	}
+}
+
+// WithCaptureContent enables embedding response bodies in HAR entries.
+func WithCaptureContent(capture bool) Option {
+	return func(r *Recorder) error {
+		r.captureContent = capture
+		return nil
+	}
+}
+
+// WithMaxBodySize skips response bodies larger than size bytes.
+// A size of zero or less means no limit.
+func WithMaxBodySize(size int64) Option {
+	return func(r *Recorder) error {
+		r.maxBodySize = size
+		return nil
+	}
+}
+
+// WithOutput sets the destination for streamed entries (default os.Stdout).
+func WithOutput(w io.Writer) Option {
+	return func(r *Recorder) error {
+		r.output = w
+		return nil
+	}
+}
+
 func New(opts ...Option) (*Recorder, error) {
 	r := &Recorder{
-		requests:  make(map[network.RequestID]*network.Request),
-		responses: make(map[network.RequestID]*network.Response),
-		bodies:    make(map[network.RequestID][]byte),
-		timings:   make(map[network.RequestID]*network.EventLoadingFinished),
+		requests:     make(map[network.RequestID]*network.Request),
+		responses:    make(map[network.RequestID]*network.Response),
+		bodies:       make(map[network.RequestID][]byte),
+		timings:      make(map[network.RequestID]*network.EventLoadingFinished),
+		started:      make(map[network.RequestID]time.Time),
+		sentAt:       make(map[network.RequestID]time.Time),
+		pageRefs:     make(map[network.RequestID]string),
+		inflight:     make(map[network.RequestID]bool),
+		lastActivity: time.Now(),
+		output:       os.Stdout,
+		fetchBody: func(ctx context.Context, id network.RequestID) ([]byte, error) {
+			return network.GetResponseBody(id).Do(ctx)
+		},
 	}
 
 	for _, opt := range opts {
@@ -92,20 +191,19 @@ func (r *Recorder) HandleNetworkEvent(ctx context.Context) func(interface{}) {
 			if r.verbose {
 				log.Printf("Request: %s %s", e.Request.Method, e.Request.URL)
 			}
-			r.requests[e.RequestID] = e.Request
-
-			if r.streaming {
-				entry := &har.Entry{
-					StartedDateTime: time.Now().Format(time.RFC3339),
-					Request: &har.Request{
-						Method:      e.Request.Method,
-						URL:         e.Request.URL,
-						HTTPVersion: "HTTP/1.1", // Default to HTTP/1.1 as Protocol isn't available
-						Headers:     convertHeaders(e.Request.Headers),
-					},
+			if _, seen := r.requests[e.RequestID]; !seen {
+				r.order = append(r.order, e.RequestID)
+				r.started[e.RequestID] = time.Now()
+				if e.Timestamp != nil {
+					r.sentAt[e.RequestID] = e.Timestamp.Time()
 				}
-				r.streamEntry(entry)
 			}
+			r.requests[e.RequestID] = e.Request
+			if len(r.pages) > 0 {
+				r.pageRefs[e.RequestID] = r.pages[len(r.pages)-1].ID
+			}
+			r.inflight[e.RequestID] = true
+			r.lastActivity = time.Now()
 
 		case *network.EventResponseReceived:
 			if r.verbose {
@@ -113,49 +211,137 @@ func (r *Recorder) HandleNetworkEvent(ctx context.Context) func(interface{}) {
 			}
 			r.responses[e.RequestID] = e.Response
 
-			if r.streaming {
-				entry := &har.Entry{
-					StartedDateTime: time.Now().Format(time.RFC3339),
-					Request: &har.Request{
-						Method: r.requests[e.RequestID].Method,
-						URL:    e.Response.URL,
-					},
-					Response: &har.Response{
-						Status:      int64(e.Response.Status),
-						StatusText:  e.Response.StatusText,
-						HTTPVersion: e.Response.Protocol,
-						Headers:     convertHeaders(e.Response.Headers),
-						Content: &har.Content{
-							MimeType: e.Response.MimeType,
-							Size:     int64(e.Response.EncodedDataLength),
-						},
-					},
-				}
-				r.streamEntry(entry)
+		case *page.EventDomContentEventFired:
+			if p := r.currentPage(); p != nil {
+				p.PageTimings.OnContentLoad = sinceMillis(r.pageStart)
+			}
+
+		case *page.EventLoadEventFired:
+			if p := r.currentPage(); p != nil {
+				p.PageTimings.OnLoad = sinceMillis(r.pageStart)
 			}
 
+		case *network.EventLoadingFailed:
+			delete(r.inflight, e.RequestID)
+			r.lastActivity = time.Now()
+
 		case *network.EventLoadingFinished:
 			r.timings[e.RequestID] = e
+			delete(r.inflight, e.RequestID)
+			r.lastActivity = time.Now()
 
+			req := r.requests[e.RequestID]
+			if req == nil || !r.shouldCapture(req.URL) {
+				return
+			}
+			if r.captureContent && !r.drained && r.withinBodyLimit(int64(e.EncodedDataLength)) {
+				r.pending.Add(1)
+				go r.captureBody(ctx, e.RequestID)
+				return
+			}
 			if r.streaming {
-				go func(reqID network.RequestID) {
-					body, err := network.GetResponseBody(reqID).Do(ctx)
-					if err != nil {
-						if r.verbose {
-							log.Printf("Error getting response body: %v", err)
-						}
-						return
-					}
-					r.Lock()
-					r.bodies[reqID] = body
-					r.Unlock()
-				}(e.RequestID)
+				r.streamEntry(r.createHAREntry(e.RequestID))
 			}
 		}
 	}
 }
 
+// StartPage begins a new HAR page for a navigation to url. Requests sent
+// after StartPage are attributed to the page, and its page timings are
+// measured from this call.
+func (r *Recorder) StartPage(url string) {
+	r.Lock()
+	defer r.Unlock()
+
+	r.pageStart = time.Now()
+	r.pages = append(r.pages, &har.Page{
+		StartedDateTime: r.pageStart.Format(time.RFC3339Nano),
+		ID:              fmt.Sprintf("page_%d", len(r.pages)+1),
+		Title:           url,
+		PageTimings:     &har.PageTimings{},
+	})
+}
+
+func (r *Recorder) currentPage() *har.Page {
+	if len(r.pages) == 0 {
+		return nil
+	}
+	return r.pages[len(r.pages)-1]
+}
+
+func sinceMillis(t time.Time) float64 {
+	return float64(time.Since(t)) / float64(time.Millisecond)
+}
+
+// captureBody fetches the response body for id and records it,
+// streaming the completed entry if streaming is enabled.
+func (r *Recorder) captureBody(ctx context.Context, id network.RequestID) {
+	defer r.pending.Done()
+
+	body, err := r.fetchBody(ctx, id)
+	if err != nil && r.verbose {
+		log.Printf("Error getting response body: %v", err)
+	}
+
+	r.Lock()
+	defer r.Unlock()
+	if err == nil {
+		if r.withinBodyLimit(int64(len(body))) {
+			r.bodies[id] = body
+		} else if r.verbose {
+			log.Printf("Skipping response body of %d bytes for %s", len(body), r.requests[id].URL)
+		}
+	}
+	if r.streaming {
+		r.streamEntry(r.createHAREntry(id))
+	}
+}
+
+// WaitForNetworkIdle blocks until no requests have been in flight for
+// the idle duration, or ctx is done.
+func (r *Recorder) WaitForNetworkIdle(ctx context.Context, idle time.Duration) error {
+	interval := idle / 4
+	if interval < 10*time.Millisecond {
+		interval = 10 * time.Millisecond
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		r.Lock()
+		quiet := len(r.inflight) == 0 && time.Since(r.lastActivity) >= idle
+		r.Unlock()
+		if quiet {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// shouldCapture reports whether url passes the URL and omit patterns.
+func (r *Recorder) shouldCapture(url string) bool {
+	if r.urlPattern != nil && !r.urlPattern.MatchString(url) {
+		return false
+	}
+	if r.omitPattern != nil && r.omitPattern.MatchString(url) {
+		return false
+	}
+	return true
+}
+
+func (r *Recorder) withinBodyLimit(size int64) bool {
+	return r.maxBodySize <= 0 || size <= r.maxBodySize
+}
+
 func (r *Recorder) streamEntry(entry *har.Entry) {
+	if entry == nil {
+		return
+	}
 	if r.filter != nil && r.filter.JQExpr != "" {
 		filtered, err := r.applyJQFilter(entry)
 		if err != nil {
@@ -188,10 +374,24 @@ func (r *Recorder) streamEntry(entry *har.Entry) {
 		}
 		return
 	}
-	fmt.Println(string(jsonBytes))
+	fmt.Fprintln(r.output, string(jsonBytes))
 }
 
+// Drain stops the recorder from fetching further response bodies and
+// waits for the fetches already in flight. Requests that finish later
+// are recorded without their bodies.
+func (r *Recorder) Drain() {
+	r.Lock()
+	r.drained = true
+	r.Unlock()
+	r.pending.Wait()
+}
+
+// WriteHAR drains the recorder and writes the captured entries to
+// filename as a HAR file.
 func (r *Recorder) WriteHAR(filename string) error {
+	r.Drain()
+
 	r.Lock()
 	defer r.Unlock()
 
@@ -206,49 +406,15 @@ func (r *Recorder) WriteHAR(filename string) error {
 				Name:    "chrome-to-har",
 				Version: "1.0",
 			},
-			Pages:   make([]*har.Page, 0),
+			Pages:   append(make([]*har.Page, 0, len(r.pages)), r.pages...),
 			Entries: make([]*har.Entry, 0),
 		},
 	}
 
-	for reqID, req := range r.requests {
-		resp := r.responses[reqID]
-		if resp == nil {
-			continue
-		}
-
-		timing := r.timings[reqID]
-		if timing == nil {
-			continue
-		}
-
-		entry := &har.Entry{
-			StartedDateTime: time.Now().Format(time.RFC3339),
-			Request: &har.Request{
-				Method:      req.Method,
-				URL:         req.URL,
-				HTTPVersion: "HTTP/1.1", // Default to HTTP/1.1
-				Headers:     convertHeaders(req.Headers),
-				Cookies:     r.convertCookies(req.Headers),
-			},
-			Response: &har.Response{
-				Status:      int64(resp.Status),
-				StatusText:  resp.StatusText,
-				HTTPVersion: resp.Protocol,
-				Headers:     convertHeaders(resp.Headers),
-				Content: &har.Content{
-					Size:     int64(resp.EncodedDataLength),
-					MimeType: resp.MimeType,
-				},
-			},
-			Time: float64(timing.Timestamp.Time().UnixNano()) / float64(time.Millisecond),
-		}
-
-		if body, ok := r.bodies[reqID]; ok {
-			entry.Response.Content.Text = string(body)
+	for _, reqID := range r.order {
+		if entry := r.createHAREntry(reqID); entry != nil {
+			h.Log.Entries = append(h.Log.Entries, entry)
 		}
-
-		h.Log.Entries = append(h.Log.Entries, entry)
 	}
 
 	jsonBytes, err := json.MarshalIndent(h, "", "  ")
@@ -263,6 +429,87 @@ func (r *Recorder) WriteHAR(filename string) error {
 	return nil
 }
 
+// createHAREntry builds the HAR entry for a completed request.
+// It returns nil if the request has not finished or is filtered out.
+// The caller must hold the lock.
+func (r *Recorder) createHAREntry(reqID network.RequestID) *har.Entry {
+	req := r.requests[reqID]
+	resp := r.responses[reqID]
+	timing := r.timings[reqID]
+	if req == nil || resp == nil || timing == nil {
+		return nil
+	}
+	if !r.shouldCapture(req.URL) {
+		return nil
+	}
+
+	started, ok := r.started[reqID]
+	if !ok {
+		started = time.Now()
+	}
+
+	entry := &har.Entry{
+		Pageref:         r.pageRefs[reqID],
+		StartedDateTime: started.Format(time.RFC3339Nano),
+		Request: &har.Request{
+			Method:      req.Method,
+			URL:         req.URL,
+			HTTPVersion: "HTTP/1.1", // Default to HTTP/1.1
+			Headers:     convertHeaders(req.Headers),
+			Cookies:     r.convertCookies(req.Headers),
+		},
+		Response: &har.Response{
+			Status:      int64(resp.Status),
+			StatusText:  resp.StatusText,
+			HTTPVersion: resp.Protocol,
+			Headers:     convertHeaders(resp.Headers),
+			Content: &har.Content{
+				Size:     int64(resp.EncodedDataLength),
+				MimeType: resp.MimeType,
+			},
+		},
+	}
+	// The entry time is the request's total duration. Both timestamps
+	// come from the browser's monotonic clock, which is unrelated to the
+	// wall-clock time in started.
+	if sent, ok := r.sentAt[reqID]; ok && timing.Timestamp != nil {
+		if d := timing.Timestamp.Time().Sub(sent); d > 0 {
+			entry.Time = float64(d) / float64(time.Millisecond)
+		}
+	}
+
+	if body, ok := r.bodies[reqID]; ok {
+		content := entry.Response.Content
+		content.Size = int64(len(body))
+		if isText(resp.MimeType, body) {
+			content.Text = string(body)
+		} else {
+			content.Text = base64.StdEncoding.EncodeToString(body)
+			content.Encoding = "base64"
+		}
+	}
+
+	return entry
+}
+
+// isText reports whether a body with the given MIME type can be embedded
+// in a HAR as plain text rather than base64.
+func isText(mimeType string, body []byte) bool {
+	if !utf8.Valid(body) {
+		return false
+	}
+	mimeType = strings.ToLower(mimeType)
+	switch {
+	case strings.HasPrefix(mimeType, "text/"),
+		strings.Contains(mimeType, "json"),
+		strings.Contains(mimeType, "javascript"),
+		strings.Contains(mimeType, "xml"),
+		strings.Contains(mimeType, "x-www-form-urlencoded"):
+		return true
+	}
+	return false
+}
+
 func convertHeaders(headers map[string]interface{}) []*har.NameValuePair {
 	pairs := make([]*har.NameValuePair, 0, len(headers))
 	for name, value := range headers {
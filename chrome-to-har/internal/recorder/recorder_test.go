@@ -3,14 +3,22 @@ package recorder
 import (
 	"context"
 	"encoding/json"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/chromedp/cdproto/cdp"
 	"github.com/chromedp/cdproto/har"
 	"github.com/chromedp/cdproto/network"
 )
 
+func monotonicNow() *cdp.MonotonicTime {
+	ts := cdp.MonotonicTime(time.Now())
+	return &ts
+}
+
 func TestRecorderStreaming(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -45,7 +53,7 @@ func TestRecorderStreaming(t *testing.T) {
 				},
 				&network.EventLoadingFinished{
 					RequestID: "1",
-					Timestamp: network.MonotonicTime(time.Now().UnixNano() / int64(time.Millisecond)),
+					Timestamp: monotonicNow(),
 				},
 			},
 			want: 1,
@@ -74,7 +82,8 @@ func TestRecorderStreaming(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			opts := []Option{WithStreaming(tt.streaming)}
+			var output strings.Builder
+			opts := []Option{WithStreaming(tt.streaming), WithOutput(&output)}
 			if tt.name == "streaming_with_filtered_url" {
 				opts = append(opts, WithURLPattern("example\\.com"))
 			}
@@ -87,11 +96,6 @@ func TestRecorderStreaming(t *testing.T) {
 			ctx := context.Background()
 			handler := rec.HandleNetworkEvent(ctx)
 
-			// Capture stdout
-			var output strings.Builder
-			oldStdout := json.NewEncoder(&output)
-			_ = oldStdout // Avoid unused variable warning
-
 			// Process events
 			for _, event := range tt.events {
 				handler(event)
@@ -144,7 +148,7 @@ func TestCreateHAREntry(t *testing.T) {
 			},
 			timing: &network.EventLoadingFinished{
 				RequestID: "test1",
-				Timestamp: network.MonotonicTime(time.Now().UnixNano() / int64(time.Millisecond)),
+				Timestamp: monotonicNow(),
 			},
 			wantURL: "https://example.com",
 			wantErr: false,
@@ -195,3 +199,291 @@ func TestCreateHAREntry(t *testing.T) {
 		})
 	}
 }
+
+func TestCaptureContent(t *testing.T) {
+	tests := []struct {
+		name         string
+		opts         []Option
+		url          string
+		mimeType     string
+		body         []byte
+		wantText     string
+		wantEncoding string
+	}{
+		{
+			name:     "json_body",
+			opts:     []Option{WithCaptureContent(true)},
+			url:      "https://example.com/api.json",
+			mimeType: "application/json",
+			body:     []byte(`{"ok":true}`),
+			wantText: `{"ok":true}`,
+		},
+		{
+			name:         "binary_body",
+			opts:         []Option{WithCaptureContent(true)},
+			url:          "https://example.com/image.png",
+			mimeType:     "image/png",
+			body:         []byte{0x89, 'P', 'N', 'G', 0xff},
+			wantText:     "iVBOR/8=",
+			wantEncoding: "base64",
+		},
+		{
+			name:     "body_too_large",
+			opts:     []Option{WithCaptureContent(true), WithMaxBodySize(4)},
+			url:      "https://example.com/api.json",
+			mimeType: "application/json",
+			body:     []byte(`{"ok":true}`),
+		},
+		{
+			name:     "capture_disabled",
+			url:      "https://example.com/api.json",
+			mimeType: "application/json",
+			body:     []byte(`{"ok":true}`),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec, err := New(tt.opts...)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			rec.fetchBody = func(ctx context.Context, id network.RequestID) ([]byte, error) {
+				return tt.body, nil
+			}
+
+			handler := rec.HandleNetworkEvent(context.Background())
+			handler(&network.EventRequestWillBeSent{
+				RequestID: "1",
+				Request:   &network.Request{URL: tt.url, Method: "GET"},
+			})
+			handler(&network.EventResponseReceived{
+				RequestID: "1",
+				Response:  &network.Response{URL: tt.url, Status: 200, MimeType: tt.mimeType},
+			})
+			handler(&network.EventLoadingFinished{
+				RequestID:         "1",
+				Timestamp:         monotonicNow(),
+				EncodedDataLength: float64(len(tt.body)),
+			})
+
+			filename := filepath.Join(t.TempDir(), "out.har")
+			if err := rec.WriteHAR(filename); err != nil {
+				t.Fatalf("WriteHAR() error = %v", err)
+			}
+			data, err := os.ReadFile(filename)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var h har.HAR
+			if err := json.Unmarshal(data, &h); err != nil {
+				t.Fatalf("unmarshal HAR: %v", err)
+			}
+			if len(h.Log.Entries) != 1 {
+				t.Fatalf("got %d entries, want 1", len(h.Log.Entries))
+			}
+
+			content := h.Log.Entries[0].Response.Content
+			if content.Text != tt.wantText {
+				t.Errorf("content.text = %q, want %q", content.Text, tt.wantText)
+			}
+			if content.Encoding != tt.wantEncoding {
+				t.Errorf("content.encoding = %q, want %q", content.Encoding, tt.wantEncoding)
+			}
+		})
+	}
+}
+
+func TestCaptureContentSkipsOmitted(t *testing.T) {
+	rec, err := New(WithCaptureContent(true), WithOmitPattern(`\.png$`))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	rec.fetchBody = func(ctx context.Context, id network.RequestID) ([]byte, error) {
+		t.Errorf("fetchBody called for omitted request %s", id)
+		return nil, nil
+	}
+
+	handler := rec.HandleNetworkEvent(context.Background())
+	handler(&network.EventRequestWillBeSent{
+		RequestID: "1",
+		Request:   &network.Request{URL: "https://example.com/logo.png", Method: "GET"},
+	})
+	handler(&network.EventResponseReceived{
+		RequestID: "1",
+		Response:  &network.Response{URL: "https://example.com/logo.png", Status: 200},
+	})
+	handler(&network.EventLoadingFinished{RequestID: "1", Timestamp: monotonicNow()})
+
+	if err := rec.WriteHAR(filepath.Join(t.TempDir(), "out.har")); err != nil {
+		t.Fatalf("WriteHAR() error = %v", err)
+	}
+	if entry := rec.createHAREntry("1"); entry != nil {
+		t.Errorf("createHAREntry() = %+v, want nil for omitted URL", entry)
+	}
+}
+
+func TestWaitForNetworkIdle(t *testing.T) {
+	rec, err := New()
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	handler := rec.HandleNetworkEvent(context.Background())
+	handler(&network.EventRequestWillBeSent{
+		RequestID: "1",
+		Request:   &network.Request{URL: "https://example.com/slow", Method: "GET"},
+	})
+
+	// A request is in flight, so the wait must not succeed.
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := rec.WaitForNetworkIdle(ctx, 20*time.Millisecond); err == nil {
+		t.Fatal("WaitForNetworkIdle() returned nil with a request in flight")
+	}
+
+	handler(&network.EventLoadingFailed{RequestID: "1", Timestamp: monotonicNow()})
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	start := time.Now()
+	if err := rec.WaitForNetworkIdle(ctx, 20*time.Millisecond); err != nil {
+		t.Fatalf("WaitForNetworkIdle() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Errorf("WaitForNetworkIdle() returned after %v, want at least 20ms", elapsed)
+	}
+}
+
+func TestPages(t *testing.T) {
+	rec, err := New()
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	handler := rec.HandleNetworkEvent(context.Background())
+	load := func(id network.RequestID, url string) {
+		handler(&network.EventRequestWillBeSent{
+			RequestID: id,
+			Request:   &network.Request{URL: url, Method: "GET"},
+		})
+		handler(&network.EventResponseReceived{
+			RequestID: id,
+			Response:  &network.Response{URL: url, Status: 200},
+		})
+		handler(&network.EventLoadingFinished{RequestID: id, Timestamp: monotonicNow()})
+	}
+
+	rec.StartPage("https://example.com/")
+	load("1", "https://example.com/")
+	load("2", "https://example.com/app.js")
+	rec.StartPage("https://example.org/")
+	load("3", "https://example.org/")
+
+	filename := filepath.Join(t.TempDir(), "out.har")
+	if err := rec.WriteHAR(filename); err != nil {
+		t.Fatalf("WriteHAR() error = %v", err)
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var h har.HAR
+	if err := json.Unmarshal(data, &h); err != nil {
+		t.Fatalf("unmarshal HAR: %v", err)
+	}
+
+	if len(h.Log.Pages) != 2 {
+		t.Fatalf("got %d pages, want 2", len(h.Log.Pages))
+	}
+	if got, want := h.Log.Pages[1].Title, "https://example.org/"; got != want {
+		t.Errorf("pages[1].title = %q, want %q", got, want)
+	}
+
+	wantRefs := []string{h.Log.Pages[0].ID, h.Log.Pages[0].ID, h.Log.Pages[1].ID}
+	if len(h.Log.Entries) != len(wantRefs) {
+		t.Fatalf("got %d entries, want %d", len(h.Log.Entries), len(wantRefs))
+	}
+	for i, entry := range h.Log.Entries {
+		if entry.Pageref != wantRefs[i] {
+			t.Errorf("entries[%d].pageref = %q, want %q", i, entry.Pageref, wantRefs[i])
+		}
+	}
+}
+
+func TestDrain(t *testing.T) {
+	rec, err := New(WithCaptureContent(true))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	release := make(chan struct{})
+	fetched := make(chan network.RequestID, 2)
+	rec.fetchBody = func(ctx context.Context, id network.RequestID) ([]byte, error) {
+		fetched <- id
+		<-release
+		return []byte("body"), nil
+	}
+
+	handler := rec.HandleNetworkEvent(context.Background())
+	finish := func(id network.RequestID) {
+		url := "https://example.com/" + string(id)
+		handler(&network.EventRequestWillBeSent{
+			RequestID: id,
+			Request:   &network.Request{URL: url, Method: "GET"},
+		})
+		handler(&network.EventResponseReceived{
+			RequestID: id,
+			Response:  &network.Response{URL: url, Status: 200, MimeType: "text/plain"},
+		})
+		handler(&network.EventLoadingFinished{RequestID: id, Timestamp: monotonicNow()})
+	}
+
+	finish("1")
+	<-fetched
+	drained := make(chan struct{})
+	go func() {
+		rec.Drain()
+		close(drained)
+	}()
+	// Drain must not return while the first body is being fetched, and
+	// requests finishing meanwhile must not start new fetches.
+	for {
+		rec.Lock()
+		done := rec.drained
+		rec.Unlock()
+		if done {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	finish("2")
+	select {
+	case <-drained:
+		t.Fatal("Drain returned before the in-flight fetch finished")
+	case id := <-fetched:
+		t.Fatalf("fetchBody called for %s after Drain", id)
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	<-drained
+
+	filename := filepath.Join(t.TempDir(), "out.har")
+	if err := rec.WriteHAR(filename); err != nil {
+		t.Fatalf("WriteHAR() error = %v", err)
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var h har.HAR
+	if err := json.Unmarshal(data, &h); err != nil {
+		t.Fatalf("unmarshal HAR: %v", err)
+	}
+	if len(h.Log.Entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(h.Log.Entries))
+	}
+	if got := h.Log.Entries[0].Response.Content.Text; got != "body" {
+		t.Errorf("entry 1 content.text = %q, want %q", got, "body")
+	}
+	if got := h.Log.Entries[1].Response.Content.Text; got != "" {
+		t.Errorf("entry 2 content.text = %q, want none", got)
+	}
+}
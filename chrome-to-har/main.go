@@ -2,19 +2,24 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"os/signal"
 	"strings"
 	"syscall"
 	"text/tabwriter"
+	"time"
 
 	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/page"
 	"github.com/chromedp/chromedp"
 	"github.com/pkg/errors"
 	"github.com/tmc/misc/chrome-to-har/internal/chromeprofiles"
+	"github.com/tmc/misc/chrome-to-har/internal/hardiff"
 	"github.com/tmc/misc/chrome-to-har/internal/recorder"
 	"github.com/tmc/misc/chrome-to-har/internal/termmd"
 )
@@ -25,6 +30,7 @@ type options struct {
 	differential   bool
 	verbose        bool
 	startURL       string
+	urls           []string
 	cookiePattern  string
 	urlPattern     string
 	blockPattern   string
@@ -36,6 +42,15 @@ type options struct {
 	headless       bool
 	filter         string
 	template       string
+	captureContent bool
+	maxBodySize    int64
+	waitSelector   string
+	waitIdle       time.Duration
+	timeout        time.Duration
+	harDiff        bool
+	jsonOutput     bool
+	diffQuery      string
+	diffThreshold  time.Duration
 }
 
 type Runner struct {
@@ -54,7 +69,8 @@ func init() {
 		fmt.Fprintf(w, "chrome-to-har - Chrome network activity capture tool\n\n")
 		fmt.Fprintf(w, "Version: %s\n\n", Version)
 		fmt.Fprintf(w, "Usage:\n")
-		fmt.Fprintf(w, "  chrome-to-har [options]\n\n")
+		fmt.Fprintf(w, "  chrome-to-har [options] [url...]\n")
+		fmt.Fprintf(w, "  chrome-to-har -har-diff [-json] a.har b.har\n\n")
 		fmt.Fprintf(w, "Options:\n")
 
 		lines := make([]string, 0)
@@ -110,8 +126,25 @@ func main() {
 	flag.BoolVar(&opts.headless, "headless", false, "Run Chrome in headless mode")
 	flag.StringVar(&opts.filter, "filter", "", "JQ expression to filter HAR entries")
 	flag.StringVar(&opts.template, "template", "", "Go template to transform HAR entries")
+	flag.BoolVar(&opts.captureContent, "capture-content", false, "Embed response bodies in HAR entries")
+	flag.Int64Var(&opts.maxBodySize, "max-body-size", 1<<20, "Maximum response body size in bytes to embed (0 for no limit)")
+	flag.StringVar(&opts.waitSelector, "wait-selector", "", "CSS selector to wait for after navigation before finishing capture")
+	flag.DurationVar(&opts.waitIdle, "wait-idle", 0, "Finish capture after no network activity for this long (e.g. 500ms)")
+	flag.DurationVar(&opts.timeout, "timeout", 30*time.Second, "Maximum time to spend in -wait-selector and -wait-idle")
+	flag.BoolVar(&opts.harDiff, "har-diff", false, "Compare two HAR files given as arguments instead of capturing")
+	flag.BoolVar(&opts.jsonOutput, "json", false, "Output -har-diff results as JSON")
+	flag.StringVar(&opts.diffQuery, "diff-query", "sort", "Query string matching for -har-diff: keep, sort, or ignore")
+	flag.DurationVar(&opts.diffThreshold, "diff-threshold", 100*time.Millisecond, "Minimum average time increase reported as a regression by -har-diff")
 
 	flag.Parse()
+	opts.urls = flag.Args()
+
+	if opts.harDiff {
+		if err := runHARDiff(os.Stdout, opts); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
 
 	if opts.listProfiles {
 		if err := listAvailableProfiles(opts.verbose); err != nil {
@@ -153,6 +186,37 @@ func listAvailableProfiles(verbose bool) error {
 	return nil
 }
 
+// runHARDiff compares the two HAR files named in opts.urls.
+func runHARDiff(w io.Writer, opts options) error {
+	if len(opts.urls) != 2 {
+		return errors.New("-har-diff requires exactly two HAR files")
+	}
+	mode, err := hardiff.ParseQueryMode(opts.diffQuery)
+	if err != nil {
+		return err
+	}
+	a, err := hardiff.Load(opts.urls[0])
+	if err != nil {
+		return err
+	}
+	b, err := hardiff.Load(opts.urls[1])
+	if err != nil {
+		return err
+	}
+
+	res, err := hardiff.Compare(a, b, hardiff.Options{
+		Query:     mode,
+		Threshold: opts.diffThreshold,
+	})
+	if err != nil {
+		return errors.Wrap(err, "comparing HAR files")
+	}
+	if opts.jsonOutput {
+		return res.WriteJSON(w)
+	}
+	return res.WriteText(w)
+}
+
 func run(ctx context.Context, pm chromeprofiles.ProfileManager, opts options) error {
 	// Validate profile
 	if opts.profileDir == "" {
@@ -228,6 +292,10 @@ func (r *Runner) Run(ctx context.Context, opts options) error {
 		recorder.WithStreaming(opts.streaming),
 		recorder.WithFilter(opts.filter),
 		recorder.WithTemplate(opts.template),
+		recorder.WithURLPattern(opts.urlPattern),
+		recorder.WithOmitPattern(opts.omitPattern),
+		recorder.WithCaptureContent(opts.captureContent),
+		recorder.WithMaxBodySize(opts.maxBodySize),
 	)
 	if err != nil {
 		return errors.Wrap(err, "creating recorder")
@@ -236,6 +304,7 @@ func (r *Runner) Run(ctx context.Context, opts options) error {
 	// Enable network events
 	if err := chromedp.Run(taskCtx,
 		network.Enable(),
+		page.Enable(),
 		chromedp.ActionFunc(func(ctx context.Context) error {
 			chromedp.ListenTarget(ctx, rec.HandleNetworkEvent(ctx))
 			return nil
@@ -244,12 +313,38 @@ func (r *Runner) Run(ctx context.Context, opts options) error {
 		return errors.Wrap(err, "enabling network monitoring")
 	}
 
-	// Navigate if URL specified
-	if opts.startURL != "" {
-		if err := chromedp.Run(taskCtx, chromedp.Navigate(opts.startURL)); err != nil {
-			return errors.Wrap(err, "navigating to URL")
+	// Navigate to each URL in turn, recording one HAR page per URL.
+	urls := opts.navigationURLs()
+	autoFinish := opts.waitSelector != "" || opts.waitIdle > 0 || len(urls) > 1
+	for _, u := range urls {
+		if opts.verbose {
+			log.Printf("Navigating to %s", u)
+		}
+		rec.StartPage(u)
+		if err := chromedp.Run(taskCtx, chromedp.Navigate(u)); err != nil {
+			return errors.Wrapf(err, "navigating to %s", u)
+		}
+		if err := r.waitForCapture(taskCtx, rec, opts); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			log.Printf("Warning: %v; continuing with entries captured so far", err)
 		}
 	}
+	if len(urls) == 0 {
+		if err := r.waitForCapture(taskCtx, rec, opts); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			log.Printf("Warning: %v; writing HAR with entries captured so far", err)
+		}
+	}
+
+	// With wait conditions or a multi-URL journey, finish the capture
+	// once navigation is done instead of waiting for Ctrl+D.
+	if autoFinish {
+		return finishCapture(rec, opts)
+	}
 
 	// Set up signal handling
 	sigChan := make(chan os.Signal, 1)
@@ -286,15 +381,107 @@ func (r *Runner) Run(ctx context.Context, opts options) error {
 		}
 	}
 
-	if !opts.streaming {
-		if err := rec.WriteHAR(opts.outputFile); err != nil {
-			return errors.Wrap(err, "writing HAR file")
+	return finishCapture(rec, opts)
+}
+
+// finishCapture waits for in-flight response body captures and, unless
+// entries were streamed as they completed, writes the HAR file.
+func finishCapture(rec *recorder.Recorder, opts options) error {
+	if opts.streaming {
+		rec.Drain()
+		return nil
+	}
+	if err := rec.WriteHAR(opts.outputFile); err != nil {
+		return errors.Wrap(err, "writing HAR file")
+	}
+	return nil
+}
+
+// navigationURLs returns the -url flag followed by any positional URLs.
+func (opts options) navigationURLs() []string {
+	var urls []string
+	if opts.startURL != "" {
+		urls = append(urls, opts.startURL)
+	}
+	return append(urls, opts.urls...)
+}
+
+// waitForCapture blocks until the -wait-selector element exists and the
+// network has been idle for -wait-idle, bounded by -timeout.
+func (r *Runner) waitForCapture(ctx context.Context, rec *recorder.Recorder, opts options) error {
+	if opts.waitSelector == "" && opts.waitIdle <= 0 {
+		return nil
+	}
+	if opts.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.timeout)
+		defer cancel()
+	}
+
+	if opts.waitSelector != "" {
+		if opts.verbose {
+			log.Printf("Waiting for selector %q", opts.waitSelector)
+		}
+		if err := waitForSelector(ctx, opts.waitSelector); err != nil {
+			return errors.Wrapf(err, "waiting for selector %q", opts.waitSelector)
 		}
 	}
 
+	if opts.waitIdle > 0 {
+		if opts.verbose {
+			log.Printf("Waiting for %v of network idle", opts.waitIdle)
+		}
+		if err := rec.WaitForNetworkIdle(ctx, opts.waitIdle); err != nil {
+			return errors.Wrap(err, "waiting for network idle")
+		}
+	}
 	return nil
 }
 
+// waitForSelector polls document.querySelector until selector matches
+// an element or ctx is done.
+func waitForSelector(ctx context.Context, selector string) error {
+	quoted, err := json.Marshal(selector)
+	if err != nil {
+		return err
+	}
+	expr := fmt.Sprintf("document.querySelector(%s) !== null", quoted)
+	return poll(ctx, 100*time.Millisecond, func(ctx context.Context) (bool, error) {
+		var found bool
+		err := chromedp.Run(ctx, chromedp.Evaluate(expr, &found))
+		return found, err
+	})
+}
+
+// poll calls check every interval until it reports true or ctx is done.
+// Errors from check do not stop polling, since evaluation fails
+// routinely while a page navigates and its execution context is
+// replaced; the last one is included in the error returned when ctx is
+// done.
+func poll(ctx context.Context, interval time.Duration, check func(context.Context) (bool, error)) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	var lastErr error
+	for {
+		ok, err := check(ctx)
+		if err == nil && ok {
+			return nil
+		}
+		if err != nil {
+			lastErr = err
+		}
+
+		select {
+		case <-ctx.Done():
+			if lastErr != nil {
+				return fmt.Errorf("%w (last error: %v)", ctx.Err(), lastErr)
+			}
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
 func splitAndTrim(s, sep string) []string {
 	if s == "" {
 		return nil
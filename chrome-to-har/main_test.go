@@ -2,7 +2,10 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -193,3 +196,56 @@ func TestStreamingOutput(t *testing.T) {
 		})
 	}
 }
+
+func TestRunHARDiff(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.har")
+	b := filepath.Join(dir, "b.har")
+	writeFile := func(name, content string) {
+		if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile(a, `{"log":{"entries":[{"time":10,"request":{"method":"GET","url":"https://example.com/"},"response":{"status":200}}]}}`)
+	writeFile(b, `{"log":{"entries":[{"time":10,"request":{"method":"GET","url":"https://example.com/"},"response":{"status":404}}]}}`)
+
+	var out strings.Builder
+	if err := runHARDiff(&out, options{urls: []string{a, b}, diffQuery: "sort"}); err != nil {
+		t.Fatalf("runHARDiff() error = %v", err)
+	}
+	if want := "~ GET https://example.com/ status 200 -> 404"; !strings.Contains(out.String(), want) {
+		t.Errorf("runHARDiff() output = %q, want to contain %q", out.String(), want)
+	}
+
+	if err := runHARDiff(&out, options{urls: []string{a}, diffQuery: "sort"}); err == nil {
+		t.Error("runHARDiff() with one file succeeded, want error")
+	}
+}
+
+func TestPoll(t *testing.T) {
+	// Errors, such as those while a page navigates, do not end polling.
+	calls := 0
+	err := poll(context.Background(), time.Millisecond, func(context.Context) (bool, error) {
+		calls++
+		switch calls {
+		case 1, 2:
+			return false, fmt.Errorf("execution context was destroyed")
+		case 3:
+			return false, nil
+		}
+		return true, nil
+	})
+	if err != nil || calls != 4 {
+		t.Errorf("poll() = %v after %d calls, want nil after 4", err, calls)
+	}
+
+	// On timeout, the last error is reported.
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err = poll(ctx, time.Millisecond, func(context.Context) (bool, error) {
+		return false, fmt.Errorf("cannot find context")
+	})
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "cannot find context") {
+		t.Errorf("poll() = %v, want deadline exceeded with the last error", err)
+	}
+}
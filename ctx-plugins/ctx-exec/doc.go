@@ -6,6 +6,14 @@ Usage: ctx-exec [flags] command
 Flags:
   -color=true
     	Enable colored output (default: on for TTY)
+  -clear-env=false
+    	Run the command with an empty environment (plus any -env values)
+  -combined=false
+    	Capture stdout and stderr as one interleaved <output> block
+  -cwd=""
+    	Run the command in this directory
+  -env KEY=VAL
+    	Set an environment variable for the command (repeatable)
   -escape=false
     	Enable escaping of special characters in output
   -exit-code=false
@@ -16,6 +24,8 @@ Flags:
     	Specify the shell to use (default: bash or $SHELL)
   -tag=""
     	Override the output tag name (default: "exec-output")
+  -timeout=0
+    	Kill the command (and its process group) after this duration
   -x=false
     	Enable bash -x style tracing
 
@@ -58,6 +68,14 @@ const Usage = `Usage: ctx-exec [flags] command
 Flags:
   -color=true
     	Enable colored output (default: on for TTY)
+  -clear-env=false
+    	Run the command with an empty environment (plus any -env values)
+  -combined=false
+    	Capture stdout and stderr as one interleaved <output> block
+  -cwd=""
+    	Run the command in this directory
+  -env KEY=VAL
+    	Set an environment variable for the command (repeatable)
   -escape=false
     	Enable escaping of special characters in output
   -exit-code=false
@@ -68,6 +86,8 @@ Flags:
     	Specify the shell to use (default: bash or $SHELL)
   -tag=""
     	Override the output tag name (default: "exec-output")
+  -timeout=0
+    	Kill the command (and its process group) after this duration
   -x=false
     	Enable bash -x style tracing
 
@@ -1,4 +1,7 @@
 module github.com/tmc/misc/ctx-plugins/ctx-exec
 
-go 1.20
+go 1.21
 
+require rsc.io/script v0.0.2
+
+require golang.org/x/tools v0.14.0 // indirect
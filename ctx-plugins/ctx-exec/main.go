@@ -2,21 +2,48 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"html"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
+	"time"
 )
 
 var (
 	enableEscaping bool
 	outputTagName  string = "exec-output" // default tag name, can be overridden
 	jsonOutput     bool
+	useExitCode    bool
+	timeout        time.Duration
+	combined       bool
+	workDir        string
+	extraEnv       envFlag
+	clearEnv       bool
 )
 
+// envFlag collects repeated -env KEY=VAL flags.
+type envFlag []string
+
+func (e *envFlag) String() string { return strings.Join(*e, " ") }
+
+func (e *envFlag) Set(v string) error {
+	if !strings.Contains(v, "=") || strings.HasPrefix(v, "=") {
+		return fmt.Errorf("want KEY=VAL, got %q", v)
+	}
+	*e = append(*e, v)
+	return nil
+}
+
+// timeoutExitCode is the exit status used with -exit-code when the
+// command is killed by -timeout. It matches timeout(1).
+const timeoutExitCode = 124
+
 func init() {
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, Usage)
@@ -27,8 +54,20 @@ func parseFlags() {
 	flag.BoolVar(&enableEscaping, "escape", false, "Enable escaping of special characters")
 	flag.BoolVar(&jsonOutput, "json", false, "Output in JSON format")
 	flag.StringVar(&outputTagName, "tag", "exec-output", "Override the output tag name")
+	flag.BoolVar(&useExitCode, "exit-code", false, "Use the exit code of the executed command")
+	flag.DurationVar(&timeout, "timeout", 0, "Kill the command after this duration (0 means no limit)")
+	flag.BoolVar(&combined, "combined", false, "Capture stdout and stderr as a single interleaved stream")
+	flag.StringVar(&workDir, "cwd", "", "Run the command in this directory")
+	flag.Var(&extraEnv, "env", "Set an environment variable for the command (KEY=VAL, repeatable)")
+	flag.BoolVar(&clearEnv, "clear-env", false, "Start the command with an empty environment (plus any -env values)")
 	flag.Parse()
 
+	if workDir != "" {
+		if abs, err := filepath.Abs(workDir); err == nil {
+			workDir = abs
+		}
+	}
+
 	// Check for environment variables
 	if os.Getenv("CTX_EXEC_ESCAPE") == "true" {
 		enableEscaping = true
@@ -41,10 +80,22 @@ func parseFlags() {
 	}
 }
 
+// exitCodeError carries the exit status to use when -exit-code is set.
+type exitCodeError struct {
+	code int
+	err  error
+}
+
+func (e *exitCodeError) Error() string { return e.err.Error() }
+
 func main() {
 	parseFlags()
 	if err := run(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		var ec *exitCodeError
+		if errors.As(err, &ec) {
+			os.Exit(ec.code)
+		}
 		os.Exit(1)
 	}
 }
@@ -56,44 +107,122 @@ func run() error {
 	}
 
 	command := strings.Join(flag.Args(), " ")
-	stdout, stderr, err := executeCommand(command)
+	res := executeCommand(command)
 
 	var output string
 	if jsonOutput {
-		output = wrapOutputJSON(command, stdout, stderr, err)
+		output = wrapOutputJSON(command, res)
 	} else {
-		output = wrapOutput(command, stdout, stderr, err)
+		output = wrapOutput(command, res)
 	}
 	fmt.Println(output)
 
-	if err != nil {
-		return fmt.Errorf("command exited with error: %v", err)
+	if res.err == nil {
+		return nil
 	}
-	return nil
+	err := fmt.Errorf("command exited with error: %v", res.err)
+	if res.timedOut {
+		err = fmt.Errorf("command timed out after %v", timeout)
+	}
+	if !useExitCode {
+		return err
+	}
+	var exitErr *exec.ExitError
+	switch {
+	case res.timedOut:
+		return &exitCodeError{timeoutExitCode, err}
+	case errors.As(res.err, &exitErr) && exitErr.ExitCode() > 0:
+		return &exitCodeError{exitErr.ExitCode(), err}
+	}
+	return err
+}
+
+// execResult is the captured outcome of running a command. With
+// -combined, stdout and stderr are empty and output holds both streams
+// in the order they were written.
+type execResult struct {
+	stdout, stderr string
+	output         string
+	err            error
+	timedOut       bool
 }
 
-func executeCommand(command string) (string, string, error) {
-	cmd := exec.Command("bash", "-o", "pipefail", "-c", fmt.Sprintf("%s", command))
-	cmd.Env = os.Environ()
+func executeCommand(command string) execResult {
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	cmd := exec.CommandContext(ctx, "bash", "-o", "pipefail", "-c", fmt.Sprintf("%s", command))
+	cmd.Dir = workDir
+	cmd.Env = commandEnv()
+	if timeout > 0 {
+		// Kill the whole process group on timeout so that children of
+		// the shell do not keep running, or keep the output pipes open.
+		// Without a timeout the command stays in the terminal's process
+		// group, so that Ctrl-C reaches it too.
+		setProcessGroup(cmd)
+		cmd.Cancel = func() error { return killProcessGroup(cmd) }
+		cmd.WaitDelay = time.Second
+	}
 
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
+	if combined {
+		// When Stdout and Stderr are the same writer, the child gets a
+		// single pipe for both, so the kernel preserves write order.
+		cmd.Stderr = &stdout
+	}
 
 	err := cmd.Run()
-	return stdout.String(), stderr.String(), err
+	if combined {
+		return execResult{
+			output:   stdout.String(),
+			err:      err,
+			timedOut: ctx.Err() == context.DeadlineExceeded,
+		}
+	}
+	return execResult{
+		stdout:   stdout.String(),
+		stderr:   stderr.String(),
+		err:      err,
+		timedOut: ctx.Err() == context.DeadlineExceeded,
+	}
+}
+
+// commandEnv returns the environment for the command: the current
+// environment, or nothing with -clear-env, followed by the -env values.
+func commandEnv() []string {
+	var env []string
+	if !clearEnv {
+		env = os.Environ()
+	}
+	return append(env, extraEnv...)
 }
 
 type ExecOutput struct {
-	Command string `json:"cmd"`
-	Stdout  string `json:"stdout,omitempty"`
-	Stderr  string `json:"stderr,omitempty"`
-	Error   string `json:"error,omitempty"`
+	Command  string   `json:"cmd"`
+	Cwd      string   `json:"cwd,omitempty"`
+	Env      []string `json:"env,omitempty"`
+	ClearEnv bool     `json:"clear_env,omitempty"`
+	Stdout   string   `json:"stdout,omitempty"`
+	Stderr   string   `json:"stderr,omitempty"`
+	Output   string   `json:"output,omitempty"`
+	Error    string   `json:"error,omitempty"`
+	TimedOut bool     `json:"timed_out,omitempty"`
 }
 
-func wrapOutputJSON(command, stdout, stderr string, err error) string {
+func wrapOutputJSON(command string, res execResult) string {
+	stdout, stderr, err := res.stdout, res.stderr, res.err
 	output := ExecOutput{
-		Command: command,
+		Command:  command,
+		Cwd:      workDir,
+		Env:      extraEnv,
+		ClearEnv: clearEnv,
+		TimedOut: res.timedOut,
 	}
 
 	if stdout != "" {
@@ -112,6 +241,14 @@ func wrapOutputJSON(command, stdout, stderr string, err error) string {
 		}
 	}
 
+	if res.output != "" {
+		if enableEscaping {
+			output.Output = html.EscapeString(res.output)
+		} else {
+			output.Output = res.output
+		}
+	}
+
 	if err != nil {
 		if enableEscaping {
 			output.Error = html.EscapeString(err.Error())
@@ -127,11 +264,25 @@ func wrapOutputJSON(command, stdout, stderr string, err error) string {
 	return string(jsonBytes)
 }
 
-func wrapOutput(command, stdout, stderr string, err error) string {
+func wrapOutput(command string, res execResult) string {
+	stdout, stderr, err := res.stdout, res.stderr, res.err
 	escapedCommand := html.EscapeString(command)
 
 	var outputBuilder strings.Builder
-	outputBuilder.WriteString(fmt.Sprintf("<%s cmd=%q>\n", outputTagName, escapedCommand))
+	outputBuilder.WriteString(fmt.Sprintf("<%s cmd=%q", outputTagName, escapedCommand))
+	if workDir != "" {
+		outputBuilder.WriteString(fmt.Sprintf(" cwd=%q", html.EscapeString(workDir)))
+	}
+	if len(extraEnv) > 0 {
+		outputBuilder.WriteString(fmt.Sprintf(" env=%q", html.EscapeString(extraEnv.String())))
+	}
+	if clearEnv {
+		outputBuilder.WriteString(` clear-env="true"`)
+	}
+	if res.timedOut {
+		outputBuilder.WriteString(` timed-out="true"`)
+	}
+	outputBuilder.WriteString(">\n")
 
 	if stdout != "" {
 		if enableEscaping {
@@ -149,6 +300,14 @@ func wrapOutput(command, stdout, stderr string, err error) string {
 		}
 	}
 
+	if res.output != "" {
+		if enableEscaping {
+			outputBuilder.WriteString("<output>\n" + html.EscapeString(res.output) + "</output>\n")
+		} else {
+			outputBuilder.WriteString("<output>\n" + res.output + "</output>\n")
+		}
+	}
+
 	if err != nil {
 		errorMsg := err.Error()
 		if enableEscaping {
@@ -160,4 +319,3 @@ func wrapOutput(command, stdout, stderr string, err error) string {
 	outputBuilder.WriteString(fmt.Sprintf("</%s>", outputTagName))
 	return outputBuilder.String()
 }
-
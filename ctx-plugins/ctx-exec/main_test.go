@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"rsc.io/script"
+	"rsc.io/script/scripttest"
+)
+
+// TestMain lets the test binary stand in for ctx-exec: TestScripts puts
+// it on PATH under that name, and scripts run it with exec.
+func TestMain(m *testing.M) {
+	if filepath.Base(os.Args[0]) == "ctx-exec" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+func TestScripts(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("scripts use sh syntax")
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	bin := t.TempDir()
+	if err := os.Symlink(exe, filepath.Join(bin, "ctx-exec")); err != nil {
+		t.Fatal(err)
+	}
+
+	engine := script.NewEngine()
+	engine.Cmds = scripttest.DefaultCmds()
+	engine.Conds = scripttest.DefaultConds()
+	env := []string{
+		"PATH=" + bin + string(os.PathListSeparator) + os.Getenv("PATH"),
+		"HOME=" + t.TempDir(),
+	}
+	scripttest.Test(t, context.Background(), engine, env, "testdata/*.txt")
+}
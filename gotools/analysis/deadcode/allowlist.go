@@ -0,0 +1,120 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package deadcode
+
+import (
+	"bufio"
+	"fmt"
+	"go/ast"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"sync"
+)
+
+// keepDirectives are the comment directives that mark a declaration as
+// intentionally unused.
+var keepDirectives = []string{"//deadcode2:keep", "//deadcode:keep"}
+
+// HasKeepDirective reports whether any of the comment groups contains a
+// //deadcode2:keep (or //deadcode:keep) directive line.
+func HasKeepDirective(groups ...*ast.CommentGroup) bool {
+	for _, g := range groups {
+		if g == nil {
+			continue
+		}
+		for _, c := range g.List {
+			for _, d := range keepDirectives {
+				if c.Text == d || strings.HasPrefix(c.Text, d+" ") {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// An Allowlist is a set of symbol patterns that are excluded from
+// reporting. Each pattern is matched with path.Match against both
+// "importpath.Symbol" and "pkgname.Symbol"; methods are named
+// "Type.Method".
+type Allowlist struct {
+	patterns []string
+}
+
+// ReadAllowlist reads an allowlist file. Blank lines and lines starting
+// with '#' are ignored.
+func ReadAllowlist(filename string) (*Allowlist, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	a, err := ParseAllowlist(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	return a, nil
+}
+
+// ParseAllowlist parses allowlist patterns from r, one per line.
+func ParseAllowlist(r io.Reader) (*Allowlist, error) {
+	a := &Allowlist{}
+	sc := bufio.NewScanner(r)
+	for lineno := 1; sc.Scan(); lineno++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if _, err := path.Match(line, ""); err != nil {
+			return nil, fmt.Errorf("line %d: invalid pattern %q", lineno, line)
+		}
+		a.patterns = append(a.patterns, line)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Match returns the first pattern matching symbol name in the package
+// with the given import path and name, or "" if none does. A nil
+// Allowlist matches nothing.
+func (a *Allowlist) Match(pkgPath, pkgName, name string) string {
+	if a == nil {
+		return ""
+	}
+	for _, p := range a.patterns {
+		for _, s := range []string{pkgPath + "." + name, pkgName + "." + name} {
+			if ok, _ := path.Match(p, s); ok {
+				return p
+			}
+		}
+	}
+	return ""
+}
+
+// allowlistCache holds the allowlist named by the -allowlist flag, which
+// is read once and shared by all passes.
+var allowlistCache struct {
+	sync.Mutex
+	filename string
+	list     *Allowlist
+	err      error
+}
+
+func loadAllowlist(filename string) (*Allowlist, error) {
+	if filename == "" {
+		return nil, nil
+	}
+	allowlistCache.Lock()
+	defer allowlistCache.Unlock()
+	if allowlistCache.filename != filename {
+		allowlistCache.filename = filename
+		allowlistCache.list, allowlistCache.err = ReadAllowlist(filename)
+	}
+	return allowlistCache.list, allowlistCache.err
+}
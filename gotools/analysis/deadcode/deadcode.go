@@ -0,0 +1,258 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package deadcode defines an Analyzer that reports unreachable
+// functions, methods, and types within a single package.
+//
+// Unlike the whole-program deadcode command, the analyzer only sees one
+// package at a time, so it treats everything that could be reached from
+// outside the package as live: exported declarations of library
+// packages, main and init functions, package-level variables, exported
+// methods of live types, and declarations in test files. Everything
+// else that is not transitively referenced from those roots is
+// reported.
+//
+// The analyzer can be run under go vet:
+//
+//	go vet -vettool=$(which deadcode) ./...
+//
+// or wrapped with singlechecker or multichecker.
+//
+// Declarations that are intentionally unused, such as parts of a public
+// API of a main package, can be excluded from reporting with a
+// //deadcode2:keep directive in their doc comment, or by listing them
+// in a file named by the -allowlist flag. See [Allowlist] for the
+// pattern syntax.
+package deadcode
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+const doc = `report unreachable functions, methods, and types
+
+The deadcode analyzer reports package-level functions, methods, and
+types that are not reachable from the package's roots: exported
+declarations (for non-main packages), main, init, package-level
+variables, and test files.
+
+Declarations marked with a //deadcode2:keep directive, or matching a
+pattern in the -allowlist file, are not reported.`
+
+// Analyzer reports dead code within a package.
+var Analyzer = &analysis.Analyzer{
+	Name:     "deadcode",
+	Doc:      doc,
+	URL:      "https://pkg.go.dev/github.com/tmc/misc/gotools/analysis/deadcode",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+var allowlistFlag string
+
+func init() {
+	Analyzer.Flags.StringVar(&allowlistFlag, "allowlist", "", "file of symbol patterns to exclude from reporting")
+}
+
+func run(pass *analysis.Pass) (any, error) {
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+
+	allow, err := loadAllowlist(allowlistFlag)
+	if err != nil {
+		return nil, err
+	}
+	// kept reports whether obj, declared with the given comments, is
+	// excluded from reporting. Kept declarations are roots, so that
+	// whatever they use is live too.
+	kept := func(obj types.Object, groups ...*ast.CommentGroup) bool {
+		return HasKeepDirective(groups...) || allow.Match(pass.Pkg.Path(), pass.Pkg.Name(), symbolName(obj)) != ""
+	}
+
+	isMain := pass.Pkg.Name() == "main"
+	decls := make(map[types.Object]ast.Node) // *ast.FuncDecl or *ast.TypeSpec
+	methods := make(map[*types.TypeName][]*types.Func)
+	ifaceMethods := make(map[string]bool)
+	var roots []ast.Node
+
+	for _, file := range pass.Files {
+		filename := pass.Fset.File(file.Pos()).Name()
+		isTest := strings.HasSuffix(filename, "_test.go")
+		generated := ast.IsGenerated(file)
+		for _, d := range file.Decls {
+			switch d := d.(type) {
+			case *ast.FuncDecl:
+				fn, ok := pass.TypesInfo.Defs[d.Name].(*types.Func)
+				if !ok {
+					continue
+				}
+				decls[fn] = d
+				if recv := recvTypeName(fn); recv != nil {
+					methods[recv] = append(methods[recv], fn)
+				}
+				if isTest || generated || isRootFunc(fn, isMain) || kept(fn, d.Doc) {
+					roots = append(roots, d)
+				}
+			case *ast.GenDecl:
+				for _, spec := range d.Specs {
+					switch spec := spec.(type) {
+					case *ast.TypeSpec:
+						tn, ok := pass.TypesInfo.Defs[spec.Name].(*types.TypeName)
+						if !ok {
+							continue
+						}
+						decls[tn] = spec
+						if iface, ok := tn.Type().Underlying().(*types.Interface); ok {
+							for i := 0; i < iface.NumMethods(); i++ {
+								ifaceMethods[iface.Method(i).Name()] = true
+							}
+						}
+						if isTest || generated || (!isMain && tn.Exported()) || tn.Name() == "_" || kept(tn, d.Doc, spec.Doc, spec.Comment) {
+							roots = append(roots, spec)
+						}
+					default:
+						// Package-level vars and consts are always live:
+						// their initializers may have side effects.
+						roots = append(roots, spec)
+					}
+				}
+			}
+		}
+	}
+
+	live := make(map[types.Object]bool)
+	var queue []types.Object
+	mark := func(obj types.Object) {
+		if fn, ok := obj.(*types.Func); ok {
+			obj = fn.Origin()
+		}
+		if _, tracked := decls[obj]; !tracked || live[obj] {
+			return
+		}
+		live[obj] = true
+		queue = append(queue, obj)
+	}
+	visit := func(n ast.Node) {
+		ast.Inspect(n, func(n ast.Node) bool {
+			if id, ok := n.(*ast.Ident); ok {
+				if obj := pass.TypesInfo.Uses[id]; obj != nil {
+					mark(obj)
+				}
+			}
+			return true
+		})
+	}
+
+	for _, n := range roots {
+		switch n := n.(type) {
+		case *ast.FuncDecl:
+			mark(pass.TypesInfo.Defs[n.Name])
+		case *ast.TypeSpec:
+			mark(pass.TypesInfo.Defs[n.Name])
+		default:
+			visit(n)
+		}
+	}
+	for len(queue) > 0 {
+		obj := queue[0]
+		queue = queue[1:]
+		visit(decls[obj])
+
+		// A live type keeps alive the methods that could be called
+		// dynamically through an interface.
+		if tn, ok := obj.(*types.TypeName); ok {
+			for _, m := range methods[tn] {
+				if m.Exported() || ifaceMethods[m.Name()] {
+					mark(m)
+				}
+			}
+		}
+	}
+
+	// Report in declaration order so diagnostics are stable.
+	inspect.Preorder([]ast.Node{(*ast.FuncDecl)(nil), (*ast.TypeSpec)(nil)}, func(n ast.Node) {
+		var obj types.Object
+		switch n := n.(type) {
+		case *ast.FuncDecl:
+			obj = pass.TypesInfo.Defs[n.Name]
+		case *ast.TypeSpec:
+			obj = pass.TypesInfo.Defs[n.Name]
+		}
+		if obj == nil || live[obj] || obj.Name() == "_" {
+			return
+		}
+		if _, tracked := decls[obj]; !tracked {
+			return // e.g. a type declared inside a function
+		}
+		pass.Report(analysis.Diagnostic{
+			Pos:     obj.Pos(),
+			End:     obj.Pos() + token.Pos(len(obj.Name())),
+			Message: "unused " + describe(obj),
+		})
+	})
+	return nil, nil
+}
+
+// isRootFunc reports whether fn is reachable from outside the package.
+func isRootFunc(fn *types.Func, isMain bool) bool {
+	sig := fn.Type().(*types.Signature)
+	if sig.Recv() == nil {
+		switch fn.Name() {
+		case "init", "_":
+			return true
+		case "main":
+			return isMain
+		}
+		return !isMain && fn.Exported()
+	}
+	return false
+}
+
+// recvTypeName returns the named receiver type of method fn, or nil.
+func recvTypeName(fn *types.Func) *types.TypeName {
+	recv := fn.Type().(*types.Signature).Recv()
+	if recv == nil {
+		return nil
+	}
+	t := recv.Type()
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	if named, ok := t.(*types.Named); ok {
+		return named.Origin().Obj()
+	}
+	return nil
+}
+
+// symbolName returns the package-relative name of obj, such as "f",
+// "T.m", or "T".
+func symbolName(obj types.Object) string {
+	if fn, ok := obj.(*types.Func); ok {
+		if recv := recvTypeName(fn); recv != nil {
+			return recv.Name() + "." + fn.Name()
+		}
+	}
+	return obj.Name()
+}
+
+// describe returns a short description of obj, such as "function f",
+// "method T.m", or "type T".
+func describe(obj types.Object) string {
+	switch obj := obj.(type) {
+	case *types.Func:
+		if recv := recvTypeName(obj); recv != nil {
+			return "method " + recv.Name() + "." + obj.Name()
+		}
+		return "function " + obj.Name()
+	case *types.TypeName:
+		return "type " + obj.Name()
+	}
+	return obj.Name()
+}
@@ -0,0 +1,53 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package deadcode_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tmc/misc/gotools/analysis/deadcode"
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func Test(t *testing.T) {
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, deadcode.Analyzer, "a", "b")
+}
+
+func TestKeep(t *testing.T) {
+	testdata := analysistest.TestData()
+	if err := deadcode.Analyzer.Flags.Set("allowlist", filepath.Join(testdata, "allowlist.txt")); err != nil {
+		t.Fatal(err)
+	}
+	defer deadcode.Analyzer.Flags.Set("allowlist", "")
+	analysistest.Run(t, testdata, deadcode.Analyzer, "c")
+}
+
+func TestAllowlistMatch(t *testing.T) {
+	a, err := deadcode.ParseAllowlist(strings.NewReader("# comment\n\nexample.com/api.Public*\nmain.T.*\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		pkgPath, pkgName, name string
+		want                   string
+	}{
+		{"example.com/api", "api", "PublicFunc", "example.com/api.Public*"},
+		{"example.com/api", "api", "Private", ""},
+		{"example.com/other", "api", "PublicFunc", ""},
+		{"example.com/cmd", "main", "T.m", "main.T.*"},
+		{"example.com/cmd", "main", "T", ""},
+	} {
+		if got := a.Match(tt.pkgPath, tt.pkgName, tt.name); got != tt.want {
+			t.Errorf("Match(%q, %q, %q) = %q, want %q", tt.pkgPath, tt.pkgName, tt.name, got, tt.want)
+		}
+	}
+
+	if _, err := deadcode.ParseAllowlist(strings.NewReader("bad[\n")); err == nil {
+		t.Error("ParseAllowlist accepted an invalid pattern")
+	}
+}
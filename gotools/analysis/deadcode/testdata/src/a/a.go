@@ -0,0 +1,31 @@
+package main
+
+import "fmt"
+
+func main() {
+	used()
+	var s fmt.Stringer = liveType{}
+	fmt.Println(s)
+}
+
+func used() { helper() }
+
+func helper() {}
+
+func unused() { onlyFromUnused() } // want `unused function unused`
+
+func onlyFromUnused() {} // want `unused function onlyFromUnused`
+
+type liveType struct{}
+
+func (liveType) String() string { return "live" }
+
+func (liveType) private() {} // want `unused method liveType.private`
+
+type deadType struct{} // want `unused type deadType`
+
+func (deadType) String() string { return "dead" } // want `unused method deadType.String`
+
+var _ = initHelper()
+
+func initHelper() int { return 0 }
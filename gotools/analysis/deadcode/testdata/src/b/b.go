@@ -0,0 +1,24 @@
+package b
+
+// Exported is part of the package API.
+func Exported() { viaExported() }
+
+func viaExported() {}
+
+func unexported() {} // want `unused function unexported`
+
+type T struct{}
+
+func (T) Method() { t{}.m() }
+
+type t struct{}
+
+func (t) m() {}
+
+type i interface{ n() }
+
+type impl struct{}
+
+func (impl) n() {}
+
+var _ i = impl{}
@@ -0,0 +1,25 @@
+package main
+
+func main() {}
+
+//deadcode2:keep
+func kept() { keptHelper() }
+
+// keptHelper is live because a kept function calls it.
+func keptHelper() {}
+
+//deadcode:keep
+func keptOld() {}
+
+// API is part of the public surface of the command.
+//
+//deadcode2:keep
+type API struct{}
+
+func allowed() { allowedHelper() }
+
+func allowedHelper() {}
+
+func (API) allowedMethod() {}
+
+func reported() {} // want `unused function reported`
@@ -11,6 +11,7 @@ import (
 	"go/types"
 	"strings"
 
+	"github.com/tmc/misc/gotools/analysis/deadcode"
 	"golang.org/x/tools/go/callgraph/rta"
 	"golang.org/x/tools/go/packages"
 	"golang.org/x/tools/go/ssa"
@@ -24,6 +25,7 @@ type analysisResult struct {
 	deadFields    map[*types.Var]bool
 	reachablePosn map[token.Position]bool
 	typeInfo      map[*types.Interface]*types.TypeName
+	unreachable   []unreachableCode
 }
 
 func newAnalysisResult() *analysisResult {
@@ -52,8 +54,10 @@ func mainPackages(pkgs []*ssa.Package) ([]*ssa.Package, error) {
 	return mains, nil
 }
 
-// analyzeProgram performs comprehensive dead code analysis
-func analyzeProgram(prog *ssa.Program, ssaPkgs []*ssa.Package, initial []*packages.Package, _ bool) (*analysisResult, error) {
+// analyzeProgram performs comprehensive dead code analysis. Functions
+// kept by a directive or by allow are treated as roots, and reported on
+// stderr if verbose is set.
+func analyzeProgram(prog *ssa.Program, ssaPkgs []*ssa.Package, initial []*packages.Package, _ bool, allow *deadcode.Allowlist, verbose bool) (*analysisResult, error) {
 	res := newAnalysisResult()
 
 	// Find main packages
@@ -76,6 +80,13 @@ func analyzeProgram(prog *ssa.Program, ssaPkgs []*ssa.Package, initial []*packag
 		}
 	}
 
+	for _, fn := range keptRoots(prog, initial, allow, verbose) {
+		if !rootFuncs[fn] {
+			roots = append(roots, fn)
+			rootFuncs[fn] = true
+		}
+	}
+
 	// Run RTA analysis
 	rtaRes := rta.Analyze(roots, true)
 
@@ -89,6 +100,39 @@ func analyzeProgram(prog *ssa.Program, ssaPkgs []*ssa.Package, initial []*packag
 		}
 	}
 
+	// Track types referenced from the bodies of reachable functions
+	for _, pkg := range initial {
+		for _, file := range pkg.Syntax {
+			for _, decl := range file.Decls {
+				fd, ok := decl.(*ast.FuncDecl)
+				if !ok || fd.Body == nil {
+					continue
+				}
+				obj, ok := pkg.TypesInfo.Defs[fd.Name].(*types.Func)
+				if !ok {
+					continue
+				}
+				fn := prog.FuncValue(obj)
+				if fn == nil {
+					continue
+				}
+				if _, isReachable := rtaRes.Reachable[fn]; !isReachable {
+					continue
+				}
+				ast.Inspect(fd.Body, func(n ast.Node) bool {
+					if id, ok := n.(*ast.Ident); ok {
+						if tn, ok := pkg.TypesInfo.Uses[id].(*types.TypeName); ok {
+							if named, ok := tn.Type().(*types.Named); ok {
+								reachableTypes[named] = true
+							}
+						}
+					}
+					return true
+				})
+			}
+		}
+	}
+
 	// Analyze each package
 	for _, pkg := range initial {
 		for _, file := range pkg.Syntax {
@@ -130,6 +174,9 @@ func analyzeProgram(prog *ssa.Program, ssaPkgs []*ssa.Package, initial []*packag
 	if *fieldsFlag || *allFlag {
 		findDeadFields(initial, res)
 	}
+	if *unreachableFlag || *allFlag {
+		findUnreachable(initial, res)
+	}
 
 	return res, nil
 }
@@ -8,7 +8,10 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strings"
 
+	"github.com/tmc/misc/gotools/analysis/deadcode"
+	"golang.org/x/tools/go/analysis/unitchecker"
 	"golang.org/x/tools/go/packages"
 	"golang.org/x/tools/go/ssa"
 	"golang.org/x/tools/go/ssa/ssautil"
@@ -18,19 +21,35 @@ var (
 	// Existing flags
 	testFlag      = flag.Bool("test", false, "include test code")
 	formatFlag    = flag.String("format", "", "output format (template)")
-	jsonFlag      = flag.Bool("json", false, "output JSON")
+	jsonFlag      = flag.Bool("json", false, "output findings as a JSON array with file:line:col positions")
 	filterFlag    = flag.String("filter", "<module>", "filter packages")
 	generatedFlag = flag.Bool("generated", false, "include generated code")
 	whyLiveFlag   = flag.String("whylive", "", "explain why function is live")
+	allowlistFlag = flag.String("allowlist", "", "file of symbol patterns (pkg.Symbol, globs allowed) to exclude from reporting")
+	verboseFlag   = flag.Bool("v", false, "report suppressed findings on stderr")
 
 	// New flags for additional analysis
 	typesFlag  = flag.Bool("types", false, "report unreferenced types")
 	ifacesFlag = flag.Bool("ifaces", false, "report unused interfaces")
 	fieldsFlag = flag.Bool("fields", false, "report unused struct fields")
 	allFlag    = flag.Bool("all", false, "enable all dead code checks")
+
+	unreachableFlag = flag.Bool("unreachable", false, "report unreachable statements within function bodies")
+
+	// Build configuration
+	tagsFlag         = flag.String("tags", "", "comma-separated list of build tags")
+	allPlatformsFlag = flag.Bool("all-platforms", false, "report only code that is dead on every common GOOS/GOARCH")
 )
 
 func main() {
+	// When invoked as "go vet -vettool=deadcode", run the per-package
+	// analyzer under the vet protocol instead of the whole-program CLI.
+	// The vet driver registers its own flags, so start from a clean set.
+	if isVetInvocation(os.Args[1:]) {
+		flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+		unitchecker.Main(deadcode.Analyzer)
+	}
+
 	flag.Parse()
 	if err := doCallgraph("", "", *testFlag, flag.Args()); err != nil {
 		fmt.Fprintf(os.Stderr, "deadcode: %s\n", err)
@@ -38,29 +57,88 @@ func main() {
 	}
 }
 
+// isVetInvocation reports whether args look like those passed by the go
+// command to a -vettool: a version query, a flags query, or a trailing
+// JSON config file.
+func isVetInvocation(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+	switch args[0] {
+	case "-V=full", "-flags":
+		return true
+	}
+	return strings.HasSuffix(args[len(args)-1], ".cfg")
+}
+
 func doCallgraph(dir, gopath string, tests bool, args []string) error {
 	if len(args) == 0 {
 		flag.Usage()
 		return nil
 	}
 
-	// Load packages
+	var allow *deadcode.Allowlist
+	if *allowlistFlag != "" {
+		var err error
+		if allow, err = deadcode.ReadAllowlist(*allowlistFlag); err != nil {
+			return fmt.Errorf("reading allowlist: %v", err)
+		}
+	}
+
+	env := os.Environ()
+	if gopath != "" {
+		env = append(env, "GOPATH="+gopath)
+	}
+
+	if *allPlatformsFlag && *whyLiveFlag == "" {
+		var runs []platformRun
+		for _, p := range platforms {
+			fmt.Fprintf(os.Stderr, "Analyzing for %s\n", p)
+			// Cross-platform loads cannot use the host C toolchain.
+			penv := append(env[:len(env):len(env)], "GOOS="+p.goos, "GOARCH="+p.goarch, "CGO_ENABLED=0")
+			prog, initial, res, err := load(dir, penv, tests, args, allow)
+			if err != nil {
+				return fmt.Errorf("%s: %v", p, err)
+			}
+			runs = append(runs, newPlatformRun(prog.Fset, initial, res))
+		}
+		return outputPackages(mergeRuns(runs), *formatFlag, *jsonFlag)
+	}
+
+	prog, _, res, err := load(dir, env, tests, args, allow)
+	if err != nil {
+		return err
+	}
+
+	// Handle -whylive flag
+	if *whyLiveFlag != "" {
+		return explainLiveness(prog, res, *whyLiveFlag)
+	}
+
+	// Format results
+	return formatResults(prog.Fset, res, *formatFlag, *jsonFlag)
+}
+
+// load loads and analyzes the packages named by args in the build
+// environment env, and removes suppressed findings from the result.
+func load(dir string, env []string, tests bool, args []string, allow *deadcode.Allowlist) (*ssa.Program, []*packages.Package, *analysisResult, error) {
 	cfg := &packages.Config{
 		Mode:  packages.LoadAllSyntax,
 		Tests: tests,
 		Dir:   dir,
+		Env:   env,
 	}
-	if gopath != "" {
-		cfg.Env = append(os.Environ(), "GOPATH="+gopath)
+	if *tagsFlag != "" {
+		cfg.BuildFlags = []string{"-tags=" + *tagsFlag}
 	}
 
 	fmt.Fprintf(os.Stderr, "Loading packages from dir %q: %v\n", dir, args)
 	initial, err := packages.Load(cfg, args...)
 	if err != nil {
-		return fmt.Errorf("loading packages: %v", err)
+		return nil, nil, nil, fmt.Errorf("loading packages: %v", err)
 	}
 	if packages.PrintErrors(initial) > 0 {
-		return fmt.Errorf("packages contain errors")
+		return nil, nil, nil, fmt.Errorf("packages contain errors")
 	}
 
 	fmt.Fprintf(os.Stderr, "Loaded %d packages\n", len(initial))
@@ -72,10 +150,11 @@ func doCallgraph(dir, gopath string, tests bool, args []string) error {
 	fmt.Fprintf(os.Stderr, "Built SSA for %d packages\n", len(pkgs))
 
 	// Perform analysis
-	res, err := analyzeProgram(prog, pkgs, initial, tests)
+	res, err := analyzeProgram(prog, pkgs, initial, tests, allow, *verboseFlag)
 	if err != nil {
-		return fmt.Errorf("analysis failed: %v", err)
+		return nil, nil, nil, fmt.Errorf("analysis failed: %v", err)
 	}
+	suppress(prog.Fset, initial, res, allow, *verboseFlag)
 
 	// Print analysis stats
 	fmt.Fprintf(os.Stderr, "Analysis found:\n")
@@ -83,12 +162,7 @@ func doCallgraph(dir, gopath string, tests bool, args []string) error {
 	fmt.Fprintf(os.Stderr, "  Dead types: %d\n", len(res.deadTypes))
 	fmt.Fprintf(os.Stderr, "  Dead interfaces: %d\n", len(res.deadIfaces))
 	fmt.Fprintf(os.Stderr, "  Dead fields: %d\n", len(res.deadFields))
+	fmt.Fprintf(os.Stderr, "  Unreachable statements: %d\n", len(res.unreachable))
 
-	// Handle -whylive flag
-	if *whyLiveFlag != "" {
-		return explainLiveness(prog, res, *whyLiveFlag)
-	}
-
-	// Format results
-	return formatResults(prog.Fset, res, *formatFlag, *jsonFlag)
+	return prog, initial, res, nil
 }
@@ -10,6 +10,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"runtime"
 	"strconv"
 	"strings"
@@ -71,6 +72,7 @@ func Test(t *testing.T) {
 				args    []string
 				wantErr bool
 				want    map[string]bool // string -> sense
+				stderr  map[string]bool // string -> sense, matched against stderr
 			}
 			var cases []*testcase
 			var current *testcase
@@ -89,6 +91,7 @@ func Test(t *testing.T) {
 					current = &testcase{
 						linenum: i + 1,
 						want:    make(map[string]bool),
+						stderr:  make(map[string]bool),
 						args:    words[1:],
 						wantErr: kind[0] == '!',
 					}
@@ -101,6 +104,14 @@ func Test(t *testing.T) {
 						t.Fatalf("'want' directive needs argument <<%s>>", line)
 					}
 					current.want[words[1]] = kind[0] != '!'
+				case "stderr", "!stderr":
+					if current == nil {
+						t.Fatalf("'stderr' directive must be after 'deadcode'")
+					}
+					if len(words) != 2 {
+						t.Fatalf("'stderr' directive needs argument <<%s>>", line)
+					}
+					current.stderr[words[1]] = kind[0] != '!'
 				default:
 					t.Fatalf("%s: invalid directive %q", filename, kind)
 				}
@@ -145,16 +156,22 @@ func Test(t *testing.T) {
 					if err != nil {
 						got = stderr.String()
 					}
-					for pattern, want := range tc.want {
-						ok := strings.Contains(got, pattern)
-						if ok != want {
-							if want {
-								t.Errorf("missing %q in output", pattern)
-							} else {
-								t.Errorf("unwanted %q in output", pattern)
+					check := func(got string, patterns map[string]bool, where string) {
+						for pattern, want := range patterns {
+							// Match whole words so that "used" does not match "unused".
+							re := regexp.MustCompile(`(^|\W)` + regexp.QuoteMeta(pattern) + `($|\W)`)
+							ok := re.MatchString(got)
+							if ok != want {
+								if want {
+									t.Errorf("missing %q in %s", pattern, where)
+								} else {
+									t.Errorf("unwanted %q in %s", pattern, where)
+								}
 							}
 						}
 					}
+					check(got, tc.want, "output")
+					check(stderr.String(), tc.stderr, "stderr")
 				})
 			}
 		})
@@ -9,9 +9,16 @@ import (
 	"go/token"
 	"go/types"
 	"os"
+	"sort"
+	"strings"
 )
 
 func formatResults(fset *token.FileSet, res *analysisResult, format string, json bool) error {
+	return outputPackages(collectPackages(fset, res), format, json)
+}
+
+// collectPackages groups the findings in res by package.
+func collectPackages(fset *token.FileSet, res *analysisResult) []jsonPackage {
 	var packages []jsonPackage
 	pkgMap := make(map[string]*jsonPackage)
 
@@ -50,18 +57,103 @@ func formatResults(fset *token.FileSet, res *analysisResult, format string, json
 		pkg.Fields = append(pkg.Fields, fieldToJSON(field, fset))
 	}
 
-	// Convert map to slice
+	for _, u := range res.unreachable {
+		pkg := addToPackage(pkgMap, u.pkg.Path(), u.pkg.Name())
+		pkg.Unreachable = append(pkg.Unreachable, jsonUnreachable{
+			Func:     u.fn,
+			Reason:   u.reason,
+			Position: toJSONPosition(fset.Position(u.pos)),
+		})
+	}
+
+	// Convert map to slice, sorted so output is stable across runs
 	for _, pkg := range pkgMap {
+		sortPackage(pkg)
 		packages = append(packages, *pkg)
 	}
+	sort.Slice(packages, func(i, j int) bool {
+		return packages[i].Path < packages[j].Path
+	})
+	return packages
+}
 
-	// Output results
+func outputPackages(packages []jsonPackage, format string, json bool) error {
 	if json {
-		return outputJSON(packages)
+		return outputJSON(findings(packages))
 	}
 	return outputTemplate(packages, format)
 }
 
+// findings flattens packages into a list of findings ordered by position.
+// An interface reported both as an unused type and as an unused interface
+// appears once, as an interface.
+func findings(packages []jsonPackage) []jsonFinding {
+	list := make([]jsonFinding, 0)
+	ifaces := make(map[jsonPosition]bool)
+	add := func(name, kind, pkg string, pos jsonPosition) {
+		list = append(list, jsonFinding{
+			Name:     name,
+			Kind:     kind,
+			Package:  pkg,
+			Posn:     pos.String(),
+			Position: pos,
+		})
+	}
+	for _, pkg := range packages {
+		for _, fn := range pkg.Funcs {
+			if fn.Recv != "" {
+				add(strings.TrimPrefix(fn.Recv, "*")+"."+fn.Name, "method", pkg.Path, fn.Position)
+			} else {
+				add(fn.Name, "func", pkg.Path, fn.Position)
+			}
+		}
+		for _, iface := range pkg.Ifaces {
+			ifaces[iface.Position] = true
+			add(iface.Name, "interface", pkg.Path, iface.Position)
+		}
+		for _, typ := range pkg.Types {
+			if !ifaces[typ.Position] {
+				add(typ.Name, "type", pkg.Path, typ.Position)
+			}
+		}
+		for _, field := range pkg.Fields {
+			add(field.Field, "field", pkg.Path, field.Position)
+		}
+		for _, u := range pkg.Unreachable {
+			add(u.Func, "unreachable", pkg.Path, u.Position)
+			list[len(list)-1].Reason = u.Reason
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Position != list[j].Position {
+			return positionLess(list[i].Position, list[j].Position)
+		}
+		return list[i].Kind < list[j].Kind
+	})
+	return list
+}
+
+func positionLess(a, b jsonPosition) bool {
+	if a.File != b.File {
+		return a.File < b.File
+	}
+	if a.Line != b.Line {
+		return a.Line < b.Line
+	}
+	return a.Col < b.Col
+}
+
+// sortPackage orders the symbols of pkg by position.
+func sortPackage(pkg *jsonPackage) {
+	sort.Slice(pkg.Funcs, func(i, j int) bool { return positionLess(pkg.Funcs[i].Position, pkg.Funcs[j].Position) })
+	sort.Slice(pkg.Types, func(i, j int) bool { return positionLess(pkg.Types[i].Position, pkg.Types[j].Position) })
+	sort.Slice(pkg.Ifaces, func(i, j int) bool { return positionLess(pkg.Ifaces[i].Position, pkg.Ifaces[j].Position) })
+	sort.Slice(pkg.Fields, func(i, j int) bool { return positionLess(pkg.Fields[i].Position, pkg.Fields[j].Position) })
+	sort.Slice(pkg.Unreachable, func(i, j int) bool {
+		return positionLess(pkg.Unreachable[i].Position, pkg.Unreachable[j].Position)
+	})
+}
+
 // Add helper function to track type info
 type pkgInfo struct {
 	*jsonPackage
@@ -14,8 +14,13 @@ import (
 // functionToJSON converts an SSA function to JSON format
 func functionToJSON(fn *ssa.Function, fset *token.FileSet) jsonFunction {
 	pos := fset.Position(fn.Pos())
+	var recv string
+	if r := fn.Signature.Recv(); r != nil {
+		recv = types.TypeString(r.Type(), types.RelativeTo(fn.Pkg.Pkg))
+	}
 	return jsonFunction{
 		Name: fn.Name(),
+		Recv: recv,
 		Position: jsonPosition{
 			File: pos.Filename,
 			Line: pos.Line,
@@ -8,14 +8,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"strings"
 	"text/template"
 )
 
-func outputJSON(packages []jsonPackage) error {
+func outputJSON(findings []jsonFinding) error {
 	enc := json.NewEncoder(os.Stdout)
 	enc.SetIndent("", "  ")
-	return enc.Encode(packages)
+	return enc.Encode(findings)
 }
 
 func outputTemplate(packages []jsonPackage, format string) error {
@@ -26,7 +25,7 @@ func outputTemplate(packages []jsonPackage, format string) error {
 			// Output regular functions
 			for _, fn := range pkg.Funcs {
 				name := fn.Name
-				if !strings.Contains(name, ".") && !seen[name] {
+				if fn.Recv == "" && !seen[name] {
 					// Skip "used" function
 					if name != "used" {
 						fmt.Println(name)
@@ -37,15 +36,11 @@ func outputTemplate(packages []jsonPackage, format string) error {
 
 			// Output methods
 			for _, fn := range pkg.Funcs {
-				name := fn.Name
-				if strings.Contains(name, ".") {
-					parts := strings.Split(name, ".")
-					if len(parts) == 2 {
-						methodName := fmt.Sprintf("%s() method", name)
-						if !seen[methodName] {
-							fmt.Println(methodName)
-							seen[methodName] = true
-						}
+				if fn.Recv != "" {
+					methodName := fmt.Sprintf("%s() method", fn.Name)
+					if !seen[methodName] {
+						fmt.Println(methodName)
+						seen[methodName] = true
 					}
 				}
 			}
@@ -57,6 +52,20 @@ func outputTemplate(packages []jsonPackage, format string) error {
 					seen[typ.Name] = true
 				}
 			}
+
+			// Output fields
+			for _, field := range pkg.Fields {
+				fieldName := fmt.Sprintf("%s field", field.Field)
+				if !seen[fieldName] {
+					fmt.Println(fieldName)
+					seen[fieldName] = true
+				}
+			}
+
+			// Output unreachable statements
+			for _, u := range pkg.Unreachable {
+				fmt.Printf("%s: %s in %s\n", u.Position, u.Reason, u.Func)
+			}
 		}
 		return nil
 	}
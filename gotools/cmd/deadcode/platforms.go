@@ -0,0 +1,122 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"go/token"
+	"sort"
+
+	"golang.org/x/tools/go/packages"
+)
+
+type platform struct {
+	goos, goarch string
+}
+
+func (p platform) String() string { return p.goos + "/" + p.goarch }
+
+// platforms are the GOOS/GOARCH combinations analyzed by -all-platforms.
+var platforms = []platform{
+	{"linux", "amd64"},
+	{"linux", "arm64"},
+	{"darwin", "amd64"},
+	{"darwin", "arm64"},
+	{"windows", "amd64"},
+	{"windows", "arm64"},
+	{"freebsd", "amd64"},
+}
+
+// A platformRun holds the findings of one analysis and the set of files
+// that analysis loaded.
+type platformRun struct {
+	packages []jsonPackage
+	files    map[string]bool
+}
+
+func newPlatformRun(fset *token.FileSet, initial []*packages.Package, res *analysisResult) platformRun {
+	run := platformRun{
+		packages: collectPackages(fset, res),
+		files:    make(map[string]bool),
+	}
+	for _, pkg := range initial {
+		for _, file := range pkg.Syntax {
+			run.files[fset.File(file.Pos()).Name()] = true
+		}
+	}
+	return run
+}
+
+// mergeRuns returns the findings reported by every run that loaded the
+// file containing them. A helper in foo_windows.go is thus reported only
+// if it is dead on all Windows platforms, and a function in a shared
+// file only if no platform uses it.
+func mergeRuns(runs []platformRun) []jsonPackage {
+	merged := make(map[string]*jsonPackage)
+	for _, run := range runs {
+		for _, pkg := range run.packages {
+			if merged[pkg.Path] == nil {
+				merged[pkg.Path] = &jsonPackage{Name: pkg.Name, Path: pkg.Path}
+			}
+		}
+	}
+	intersect(runs, merged, func(p *jsonPackage) *[]jsonFunction { return &p.Funcs }, func(f jsonFunction) jsonPosition { return f.Position })
+	intersect(runs, merged, func(p *jsonPackage) *[]jsonType { return &p.Types }, func(t jsonType) jsonPosition { return t.Position })
+	intersect(runs, merged, func(p *jsonPackage) *[]jsonInterface { return &p.Ifaces }, func(i jsonInterface) jsonPosition { return i.Position })
+	intersect(runs, merged, func(p *jsonPackage) *[]jsonField { return &p.Fields }, func(f jsonField) jsonPosition { return f.Position })
+	intersect(runs, merged, func(p *jsonPackage) *[]jsonUnreachable { return &p.Unreachable }, func(u jsonUnreachable) jsonPosition { return u.Position })
+
+	var packages []jsonPackage
+	for _, pkg := range merged {
+		if len(pkg.Funcs)+len(pkg.Types)+len(pkg.Ifaces)+len(pkg.Fields)+len(pkg.Unreachable) == 0 {
+			continue
+		}
+		sortPackage(pkg)
+		packages = append(packages, *pkg)
+	}
+	sort.Slice(packages, func(i, j int) bool {
+		return packages[i].Path < packages[j].Path
+	})
+	return packages
+}
+
+// intersect adds to merged the entries of one kind, selected by list,
+// that are present in every run that loaded their file. Entries are
+// identified by their position.
+func intersect[T any](runs []platformRun, merged map[string]*jsonPackage, list func(*jsonPackage) *[]T, pos func(T) jsonPosition) {
+	type entry struct {
+		pkg   string
+		value T
+		count int
+	}
+	entries := make(map[jsonPosition]*entry)
+	var order []jsonPosition
+	for _, run := range runs {
+		for i := range run.packages {
+			pkg := &run.packages[i]
+			for _, v := range *list(pkg) {
+				p := pos(v)
+				e := entries[p]
+				if e == nil {
+					e = &entry{pkg: pkg.Path, value: v}
+					entries[p] = e
+					order = append(order, p)
+				}
+				e.count++
+			}
+		}
+	}
+	for _, p := range order {
+		need := 0
+		for _, run := range runs {
+			if run.files[p.File] {
+				need++
+			}
+		}
+		if e := entries[p]; e.count >= need {
+			l := list(merged[e.pkg])
+			*l = append(*l, e.value)
+		}
+	}
+}
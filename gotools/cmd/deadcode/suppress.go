@@ -0,0 +1,183 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"os"
+
+	"github.com/tmc/misc/gotools/analysis/deadcode"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/go/ssa"
+)
+
+// keepDirectives returns the declarations in pkgs marked with a
+// //deadcode2:keep directive, and the name of the struct type declaring
+// each struct field.
+func keepDirectives(pkgs []*packages.Package) (kept map[types.Object]bool, owners map[*types.Var]string) {
+	kept = make(map[types.Object]bool)
+	owners = make(map[*types.Var]string)
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Syntax {
+			ast.Inspect(file, func(n ast.Node) bool {
+				switch n := n.(type) {
+				case *ast.FuncDecl:
+					if deadcode.HasKeepDirective(n.Doc) {
+						kept[pkg.TypesInfo.Defs[n.Name]] = true
+					}
+				case *ast.GenDecl:
+					for _, spec := range n.Specs {
+						spec, ok := spec.(*ast.TypeSpec)
+						if !ok {
+							continue
+						}
+						if deadcode.HasKeepDirective(n.Doc, spec.Doc, spec.Comment) {
+							kept[pkg.TypesInfo.Defs[spec.Name]] = true
+						}
+						st, ok := spec.Type.(*ast.StructType)
+						if !ok {
+							continue
+						}
+						for _, field := range st.Fields.List {
+							for _, name := range field.Names {
+								v, ok := pkg.TypesInfo.Defs[name].(*types.Var)
+								if !ok {
+									continue
+								}
+								owners[v] = spec.Name.Name
+								if deadcode.HasKeepDirective(field.Doc, field.Comment) {
+									kept[v] = true
+								}
+							}
+						}
+					}
+				}
+				return true
+			})
+		}
+	}
+	return kept, owners
+}
+
+// suppression returns why obj, named name within its package, is
+// suppressed by kept or allow, or "" if it is not.
+func suppression(kept map[types.Object]bool, allow *deadcode.Allowlist, obj types.Object, name string) string {
+	if kept[obj] {
+		return "keep directive"
+	}
+	if p := allow.Match(obj.Pkg().Path(), obj.Pkg().Name(), name); p != "" {
+		return fmt.Sprintf("allowlist pattern %q", p)
+	}
+	return ""
+}
+
+func logSuppressed(fset *token.FileSet, obj types.Object, name, reason string) {
+	fmt.Fprintf(os.Stderr, "%s: suppressed %s.%s (%s)\n", fset.Position(obj.Pos()), obj.Pkg().Path(), name, reason)
+}
+
+// keptRoots returns the functions in pkgs that are marked with a
+// //deadcode2:keep directive or match a pattern in allow. They are
+// analysis roots, so that the functions they call are not reported
+// either. If verbose is set, each one is reported on stderr as
+// suppressed.
+func keptRoots(prog *ssa.Program, pkgs []*packages.Package, allow *deadcode.Allowlist, verbose bool) []*ssa.Function {
+	kept, _ := keepDirectives(pkgs)
+	var roots []*ssa.Function
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Syntax {
+			for _, decl := range file.Decls {
+				fd, ok := decl.(*ast.FuncDecl)
+				if !ok {
+					continue
+				}
+				obj, ok := pkg.TypesInfo.Defs[fd.Name].(*types.Func)
+				if !ok || obj.Type().(*types.Signature).TypeParams().Len() > 0 {
+					continue // generic functions have no single body to root
+				}
+				fn := prog.FuncValue(obj)
+				if fn == nil {
+					continue
+				}
+				name := funcName(fn, obj)
+				if reason := suppression(kept, allow, obj, name); reason != "" {
+					if verbose {
+						logSuppressed(prog.Fset, obj, name, reason)
+					}
+					roots = append(roots, fn)
+				}
+			}
+		}
+	}
+	return roots
+}
+
+// funcName returns the package-relative name of fn, such as "f" or
+// "T.m".
+func funcName(fn *ssa.Function, obj *types.Func) string {
+	name := obj.Name()
+	if recv := fn.Signature.Recv(); recv != nil {
+		t := recv.Type()
+		if ptr, ok := t.(*types.Pointer); ok {
+			t = ptr.Elem()
+		}
+		if named, ok := t.(*types.Named); ok {
+			name = named.Obj().Name() + "." + name
+		}
+	}
+	return name
+}
+
+// suppress removes from res the findings that are marked with a
+// //deadcode2:keep directive or match a pattern in allow. If verbose is
+// set, each suppressed finding is reported on stderr.
+func suppress(fset *token.FileSet, pkgs []*packages.Package, res *analysisResult, allow *deadcode.Allowlist, verbose bool) {
+	kept, owners := keepDirectives(pkgs)
+
+	// check reports whether obj, named name within its package, is
+	// suppressed.
+	check := func(obj types.Object, name string) bool {
+		reason := suppression(kept, allow, obj, name)
+		if reason == "" {
+			return false
+		}
+		if verbose {
+			logSuppressed(fset, obj, name, reason)
+		}
+		return true
+	}
+
+	for fn := range res.deadFuncs {
+		obj, ok := fn.Object().(*types.Func)
+		if !ok {
+			continue
+		}
+		obj = obj.Origin()
+		if check(obj, funcName(fn, obj)) {
+			delete(res.deadFuncs, fn)
+		}
+	}
+	for named := range res.deadTypes {
+		if check(named.Obj(), named.Obj().Name()) {
+			delete(res.deadTypes, named)
+		}
+	}
+	for iface := range res.deadIfaces {
+		if tn := res.typeInfo[iface]; tn != nil && check(tn, tn.Name()) {
+			delete(res.deadIfaces, iface)
+		}
+	}
+	for field := range res.deadFields {
+		name := field.Name()
+		if owner, ok := owners[field]; ok {
+			name = owner + "." + name
+		}
+		if check(field, name) {
+			delete(res.deadFields, field)
+		}
+	}
+}
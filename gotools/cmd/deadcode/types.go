@@ -4,39 +4,66 @@
 
 package main
 
-// Output types for JSON and template formatting
+import "fmt"
+
+// Output types for template formatting
 type jsonPackage struct {
-    Name      string          `json:"name"`
-    Path      string          `json:"path"`
-    Funcs     []jsonFunction  `json:"funcs,omitempty"`
-    Types     []jsonType      `json:"types,omitempty"`
-    Ifaces    []jsonInterface `json:"interfaces,omitempty"`
-    Fields    []jsonField     `json:"fields,omitempty"`
+	Name   string          `json:"name"`
+	Path   string          `json:"path"`
+	Funcs  []jsonFunction  `json:"funcs,omitempty"`
+	Types  []jsonType      `json:"types,omitempty"`
+	Ifaces []jsonInterface `json:"interfaces,omitempty"`
+	Fields []jsonField     `json:"fields,omitempty"`
+
+	Unreachable []jsonUnreachable `json:"unreachable,omitempty"`
 }
 
 type jsonFunction struct {
-    Name      string       `json:"name"`
-    Position  jsonPosition `json:"position"`
-    Generated bool        `json:"generated,omitempty"`
+	Name      string       `json:"name"`
+	Recv      string       `json:"recv,omitempty"`
+	Position  jsonPosition `json:"position"`
+	Generated bool         `json:"generated,omitempty"`
 }
 
 type jsonType struct {
-    Name     string       `json:"name"`
-    Position jsonPosition `json:"position"`
+	Name     string       `json:"name"`
+	Position jsonPosition `json:"position"`
 }
 
 type jsonInterface struct {
-    Name     string       `json:"name"`
-    Position jsonPosition `json:"position"`
+	Name     string       `json:"name"`
+	Position jsonPosition `json:"position"`
 }
 
 type jsonField struct {
-    Type     string       `json:"type"`
-    Field    string       `json:"field"`
-    Position jsonPosition `json:"position"`
+	Type     string       `json:"type"`
+	Field    string       `json:"field"`
+	Position jsonPosition `json:"position"`
+}
+
+type jsonUnreachable struct {
+	Func     string       `json:"func"`
+	Reason   string       `json:"reason"`
+	Position jsonPosition `json:"position"`
 }
 
 type jsonPosition struct {
-    File      string `json:"file"`
-    Line, Col int    `json:"line,col"`
+	File string `json:"file"`
+	Line int    `json:"line"`
+	Col  int    `json:"col"`
+}
+
+func (p jsonPosition) String() string {
+	return fmt.Sprintf("%s:%d:%d", p.File, p.Line, p.Col)
+}
+
+// jsonFinding is a single dead symbol or unreachable statement as
+// reported by -json.
+type jsonFinding struct {
+	Name     string       `json:"name"`
+	Kind     string       `json:"kind"` // func, method, type, interface, field, or unreachable
+	Reason   string       `json:"reason,omitempty"`
+	Package  string       `json:"package"`
+	Posn     string       `json:"posn"` // file:line:col
+	Position jsonPosition `json:"position"`
 }
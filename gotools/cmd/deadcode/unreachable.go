@@ -0,0 +1,148 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// unreachableCode is a statement within a function body that can never
+// execute.
+type unreachableCode struct {
+	pkg    *types.Package
+	fn     string // enclosing function, e.g. "f" or "T.m"
+	reason string
+	pos    token.Pos
+}
+
+// findUnreachable reports statements that follow an unconditional
+// return, panic, or os.Exit in the same block, and the bodies of
+// "if false" statements.
+//
+// Only the first unreachable statement of each block is reported. A
+// labeled statement ends the unreachable region, since it may be the
+// target of a goto or of a labeled break or continue; goto itself is
+// not treated as terminating for the same reason.
+func findUnreachable(pkgs []*packages.Package, res *analysisResult) {
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Syntax {
+			if ast.IsGenerated(file) && !*generatedFlag {
+				continue
+			}
+			for _, decl := range file.Decls {
+				fd, ok := decl.(*ast.FuncDecl)
+				if !ok || fd.Body == nil {
+					continue
+				}
+				name := fd.Name.Name
+				if fd.Recv != nil && len(fd.Recv.List) == 1 {
+					if recv := recvName(fd.Recv.List[0].Type); recv != "" {
+						name = recv + "." + name
+					}
+				}
+				report := func(pos token.Pos, reason string) {
+					res.unreachable = append(res.unreachable, unreachableCode{
+						pkg:    pkg.Types,
+						fn:     name,
+						reason: reason,
+						pos:    pos,
+					})
+				}
+				// Function literals are visited as part of the
+				// enclosing declaration.
+				ast.Inspect(fd.Body, func(n ast.Node) bool {
+					switch n := n.(type) {
+					case *ast.BlockStmt:
+						checkStmts(pkg.TypesInfo, n.List, report)
+					case *ast.CaseClause:
+						checkStmts(pkg.TypesInfo, n.Body, report)
+					case *ast.CommClause:
+						checkStmts(pkg.TypesInfo, n.Body, report)
+					case *ast.IfStmt:
+						if isFalse(pkg.TypesInfo, n.Cond) && len(n.Body.List) > 0 {
+							report(n.Body.List[0].Pos(), "if false block")
+						}
+					}
+					return true
+				})
+			}
+		}
+	}
+}
+
+// checkStmts reports the first statement in list that follows a
+// terminating statement.
+func checkStmts(info *types.Info, list []ast.Stmt, report func(token.Pos, string)) {
+	for i, stmt := range list {
+		reason := terminates(info, stmt)
+		if reason == "" || i+1 == len(list) {
+			continue
+		}
+		next := list[i+1]
+		if _, ok := next.(*ast.LabeledStmt); ok {
+			continue
+		}
+		if _, ok := next.(*ast.EmptyStmt); ok {
+			continue
+		}
+		report(next.Pos(), "unreachable code after "+reason)
+		return
+	}
+}
+
+// terminates returns a description of stmt, such as "return", if it
+// unconditionally leaves the function, or "" if it does not.
+func terminates(info *types.Info, stmt ast.Stmt) string {
+	switch stmt := stmt.(type) {
+	case *ast.ReturnStmt:
+		return "return"
+	case *ast.ExprStmt:
+		call, ok := ast.Unparen(stmt.X).(*ast.CallExpr)
+		if !ok {
+			return ""
+		}
+		switch fun := ast.Unparen(call.Fun).(type) {
+		case *ast.Ident:
+			if b, ok := info.Uses[fun].(*types.Builtin); ok && b.Name() == "panic" {
+				return "panic"
+			}
+		case *ast.SelectorExpr:
+			if fn, ok := info.Uses[fun.Sel].(*types.Func); ok && fn.Pkg() != nil &&
+				fn.Pkg().Path() == "os" && fn.Name() == "Exit" {
+				return "os.Exit"
+			}
+		}
+	}
+	return ""
+}
+
+// isFalse reports whether cond is the predeclared constant false,
+// possibly parenthesized. Named constants are deliberately not matched:
+// "if debug { ... }" is a common way to compile out code.
+func isFalse(info *types.Info, cond ast.Expr) bool {
+	id, ok := ast.Unparen(cond).(*ast.Ident)
+	return ok && info.Uses[id] == types.Universe.Lookup("false")
+}
+
+// recvName returns the name of the receiver base type expression x.
+func recvName(x ast.Expr) string {
+	switch x := x.(type) {
+	case *ast.StarExpr:
+		return recvName(x.X)
+	case *ast.ParenExpr:
+		return recvName(x.X)
+	case *ast.IndexExpr:
+		return recvName(x.X)
+	case *ast.IndexListExpr:
+		return recvName(x.X)
+	case *ast.Ident:
+		return x.Name
+	}
+	return ""
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// exportField extracts one typed column from an entry. Both the CSV and
+// JSON exporters are driven by exportFields so their columns stay in sync.
+type exportField struct {
+	name  string
+	value func(HAREntry) any
+}
+
+var exportFields = []exportField{
+	{"started_date_time", func(e HAREntry) any { return e.StartedDateTime.Format(time.RFC3339Nano) }},
+	{"method", func(e HAREntry) any { return e.Method }},
+	{"url", func(e HAREntry) any { return e.URL }},
+	{"domain", func(e HAREntry) any { return entryDomain(e) }},
+	{"status", func(e HAREntry) any { return e.Status }},
+	{"content_type", func(e HAREntry) any { return e.ContentType }},
+	{"resource_type", func(e HAREntry) any { return resourceType(e.ContentType) }},
+	{"response_size", func(e HAREntry) any { return e.ResponseSize }},
+	{"time", func(e HAREntry) any { return e.ResponseTime }},
+	{"blocked", func(e HAREntry) any { return e.Timings.Blocked }},
+	{"dns", func(e HAREntry) any { return e.Timings.DNS }},
+	{"connect", func(e HAREntry) any { return e.Timings.Connect }},
+	{"send", func(e HAREntry) any { return e.Timings.Send }},
+	{"wait", func(e HAREntry) any { return e.Timings.Wait }},
+	{"receive", func(e HAREntry) any { return e.Timings.Receive }},
+	{"total_duration", func(e HAREntry) any { return duration(e) }},
+}
+
+func newExportCmd(config *Config) *cobra.Command {
+	var format, fields string
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export HAR entries as flattened CSV or JSON",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			entries, err := parseHARFile(config.InputFile)
+			if err != nil {
+				return fmt.Errorf("error parsing HAR file: %w", err)
+			}
+			selected, err := selectExportFields(fields)
+			if err != nil {
+				return err
+			}
+			switch format {
+			case "csv":
+				return exportCSV(cmd.OutOrStdout(), entries, selected)
+			case "json":
+				return exportJSON(cmd.OutOrStdout(), entries, selected)
+			}
+			return fmt.Errorf("invalid --format %q (want csv or json)", format)
+		},
+	}
+	cmd.Flags().StringVarP(&format, "format", "f", "csv", "Export format (csv, json)")
+	cmd.Flags().StringVar(&fields, "fields", "", "Comma-separated fields to include (default: all)")
+	return cmd
+}
+
+// selectExportFields returns the fields named in the comma-separated list,
+// in the given order, or all fields if list is empty.
+func selectExportFields(list string) ([]exportField, error) {
+	if list == "" {
+		return exportFields, nil
+	}
+	byName := make(map[string]exportField, len(exportFields))
+	for _, f := range exportFields {
+		byName[f.name] = f
+	}
+	var selected []exportField
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		f, ok := byName[name]
+		if !ok {
+			var names []string
+			for _, f := range exportFields {
+				names = append(names, f.name)
+			}
+			return nil, fmt.Errorf("unknown field %q (available: %s)", name, strings.Join(names, ", "))
+		}
+		selected = append(selected, f)
+	}
+	return selected, nil
+}
+
+func exportCSV(w io.Writer, entries []HAREntry, fields []exportField) error {
+	writer := csv.NewWriter(w)
+	header := make([]string, len(fields))
+	for i, f := range fields {
+		header[i] = f.name
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	for _, e := range entries {
+		row := make([]string, len(fields))
+		for i, f := range fields {
+			row[i] = formatCSVValue(f.value(e))
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func formatCSVValue(v any) string {
+	if f, ok := v.(float64); ok {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
+
+func exportJSON(w io.Writer, entries []HAREntry, fields []exportField) error {
+	records := make([]map[string]any, 0, len(entries))
+	for _, e := range entries {
+		record := make(map[string]any, len(fields))
+		for _, f := range fields {
+			record[f.name] = f.value(e)
+		}
+		records = append(records, record)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(records)
+}
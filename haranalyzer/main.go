@@ -28,6 +28,48 @@ type HAREntry struct {
 	ResponseTime    float64
 	ResponseSize    int64
 	ContentType     string
+	Timings         Timings
+}
+
+// Timings holds the phases of a request in milliseconds. Phases that do
+// not apply are zero.
+type Timings struct {
+	Blocked float64
+	DNS     float64
+	Connect float64
+	Send    float64
+	Wait    float64
+	Receive float64
+}
+
+// harFile mirrors the parts of the HAR 1.2 format that haranalyzer uses.
+type harFile struct {
+	Log struct {
+		Entries []struct {
+			StartedDateTime string  `json:"startedDateTime"`
+			Time            float64 `json:"time"`
+			Request         struct {
+				Method string `json:"method"`
+				URL    string `json:"url"`
+			} `json:"request"`
+			Response struct {
+				Status   int   `json:"status"`
+				BodySize int64 `json:"bodySize"`
+				Content  struct {
+					Size     int64  `json:"size"`
+					MimeType string `json:"mimeType"`
+				} `json:"content"`
+			} `json:"response"`
+			Timings struct {
+				Blocked float64 `json:"blocked"`
+				DNS     float64 `json:"dns"`
+				Connect float64 `json:"connect"`
+				Send    float64 `json:"send"`
+				Wait    float64 `json:"wait"`
+				Receive float64 `json:"receive"`
+			} `json:"timings"`
+		} `json:"entries"`
+	} `json:"log"`
 }
 
 type FilterFunc func(HAREntry) bool
@@ -61,7 +103,7 @@ func run() error {
 		},
 	}
 
-	rootCmd.Flags().StringVarP(&config.InputFile, "input", "i", "", "Input HAR file (required)")
+	rootCmd.PersistentFlags().StringVarP(&config.InputFile, "input", "i", "", "Input HAR file (required)")
 	rootCmd.Flags().StringVarP(&config.OutputFormat, "output", "o", "text", "Output format (text, json, csv)")
 	rootCmd.Flags().StringVarP(&config.SortBy, "sort", "s", "time", "Sort entries by (time, size, status, url)")
 	rootCmd.Flags().IntVarP(&config.ChunkSize, "chunk", "c", 100, "Chunk size for analysis")
@@ -69,7 +111,11 @@ func run() error {
 	rootCmd.Flags().BoolVarP(&config.PerformAI, "ai", "a", false, "Perform AI analysis")
 	rootCmd.Flags().StringVar(&config.AnthropicKey, "anthropic-key", "", "Anthropic API key")
 
-	rootCmd.MarkFlagRequired("input")
+	rootCmd.MarkPersistentFlagRequired("input")
+
+	rootCmd.AddCommand(newExportCmd(&config))
+	rootCmd.AddCommand(newSummaryCmd(&config))
+	rootCmd.AddCommand(newWaterfallCmd(&config))
 
 	return rootCmd.Execute()
 }
@@ -113,9 +159,54 @@ func analyzeHAR(config Config) error {
 }
 
 func parseHARFile(filename string) ([]HAREntry, error) {
-	// Implement HAR file parsing logic here
-	// This is a placeholder implementation
-	return []HAREntry{}, nil
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var har harFile
+	if err := json.Unmarshal(data, &har); err != nil {
+		return nil, fmt.Errorf("decoding %s: %w", filename, err)
+	}
+
+	entries := make([]HAREntry, 0, len(har.Log.Entries))
+	for _, e := range har.Log.Entries {
+		started, err := time.Parse(time.RFC3339Nano, e.StartedDateTime)
+		if err != nil {
+			return nil, fmt.Errorf("entry %s: invalid startedDateTime: %w", e.Request.URL, err)
+		}
+		size := e.Response.BodySize
+		if size < 0 {
+			size = e.Response.Content.Size
+		}
+		entries = append(entries, HAREntry{
+			StartedDateTime: started,
+			Method:          e.Request.Method,
+			URL:             e.Request.URL,
+			Status:          e.Response.Status,
+			ResponseTime:    e.Time,
+			ResponseSize:    size,
+			ContentType:     e.Response.Content.MimeType,
+			Timings: Timings{
+				Blocked: phase(e.Timings.Blocked),
+				DNS:     phase(e.Timings.DNS),
+				Connect: phase(e.Timings.Connect),
+				Send:    phase(e.Timings.Send),
+				Wait:    phase(e.Timings.Wait),
+				Receive: phase(e.Timings.Receive),
+			},
+		})
+	}
+	return entries, nil
+}
+
+// phase converts a HAR timing value to milliseconds, mapping the HAR
+// "does not apply" value of -1 to zero.
+func phase(ms float64) float64 {
+	if ms < 0 {
+		return 0
+	}
+	return ms
 }
 
 func parseQueryString(query string) ([]FilterFunc, string, error) {
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseHARFile(t *testing.T) {
+	entries, err := parseHARFile("testdata/example.har")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 4 {
+		t.Fatalf("got %d entries, want 4", len(entries))
+	}
+
+	e := entries[1]
+	if e.URL != "https://example.com/app.js" || e.Status != 200 || e.ResponseSize != 20000 {
+		t.Errorf("entries[1] = %+v", e)
+	}
+	if e.Timings.DNS != 0 || e.Timings.Wait != 30 {
+		t.Errorf("entries[1].Timings = %+v, want dns 0 (from -1) and wait 30", e.Timings)
+	}
+	if got := entries[2].ResponseSize; got != 800 {
+		t.Errorf("entries[2].ResponseSize = %d, want content size 800 when bodySize is -1", got)
+	}
+}
+
+func TestRenderWaterfall(t *testing.T) {
+	entries, err := parseHARFile("testdata/example.har")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out strings.Builder
+	if err := renderWaterfall(&out, entries, 100); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimRight(out.String(), "\n"), "\n")
+
+	if want := "4 requests, 270ms total, 25900 bytes"; lines[0] != want {
+		t.Errorf("header = %q, want %q", lines[0], want)
+	}
+	rows := lines[3:]
+	if len(rows) != 4 {
+		t.Fatalf("got %d rows, want 4:\n%s", len(rows), out.String())
+	}
+	for i, row := range rows {
+		if len(row) != 100 {
+			t.Errorf("row %d has width %d, want 100: %q", i, len(row), row)
+		}
+	}
+
+	// The document, the script it loads, and the API call made after the
+	// script finishes form the critical path; the image does not.
+	for i, want := range []bool{true, true, false, true} {
+		if got := strings.HasPrefix(rows[i], "*"); got != want {
+			t.Errorf("row %d critical = %v, want %v: %q", i, got, want, rows[i])
+		}
+	}
+	if !strings.Contains(rows[0], "-ddcccc") {
+		t.Errorf("row 0 missing blocked/dns/connect segments: %q", rows[0])
+	}
+}
+
+func TestGroupEntries(t *testing.T) {
+	entries, err := parseHARFile("testdata/example.har")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buckets, err := groupEntries(entries, "domain")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(buckets) != 3 {
+		t.Fatalf("got %d domain buckets, want 3: %+v", len(buckets), buckets)
+	}
+	want := BucketSummary{Group: "example.com", Count: 2, TotalSize: 25000, MedianTime: 50, P95Time: 120}
+	if buckets[0] != want {
+		t.Errorf("buckets[0] = %+v, want %+v", buckets[0], want)
+	}
+
+	buckets, err = groupEntries(entries, "type")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var groups []string
+	for _, b := range buckets {
+		groups = append(groups, b.Group)
+	}
+	if got, want := strings.Join(groups, ","), "script,document,image,data"; got != want {
+		t.Errorf("type groups = %s, want %s", got, want)
+	}
+
+	if _, err := groupEntries(entries, "status"); err == nil {
+		t.Error("groupEntries with invalid key succeeded, want error")
+	}
+}
+
+func TestExport(t *testing.T) {
+	entries, err := parseHARFile("testdata/example.har")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields, err := selectExportFields("url,status,total_duration")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var csvOut strings.Builder
+	if err := exportCSV(&csvOut, entries[:1], fields); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := csvOut.String(), "url,status,total_duration\nhttps://example.com/,200,120\n"; got != want {
+		t.Errorf("exportCSV() = %q, want %q", got, want)
+	}
+
+	var jsonOut strings.Builder
+	if err := exportJSON(&jsonOut, entries[:1], fields); err != nil {
+		t.Fatal(err)
+	}
+	var records []map[string]any
+	if err := json.Unmarshal([]byte(jsonOut.String()), &records); err != nil {
+		t.Fatalf("exportJSON() produced invalid JSON: %v", err)
+	}
+	want := map[string]any{"url": "https://example.com/", "status": 200.0, "total_duration": 120.0}
+	if len(records) != 1 || !reflect.DeepEqual(records[0], want) {
+		t.Errorf("exportJSON() = %v, want [%v]", records, want)
+	}
+
+	if _, err := selectExportFields("url,bogus"); err == nil {
+		t.Error("selectExportFields with unknown field succeeded, want error")
+	}
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"mime"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// BucketSummary holds aggregate statistics for one group of entries.
+type BucketSummary struct {
+	Group      string  `json:"group"`
+	Count      int     `json:"count"`
+	TotalSize  int64   `json:"total_size"`
+	MedianTime float64 `json:"median_time"`
+	P95Time    float64 `json:"p95_time"`
+}
+
+func newSummaryCmd(config *Config) *cobra.Command {
+	var groupBy, filter string
+	var jsonOutput bool
+	cmd := &cobra.Command{
+		Use:   "summary",
+		Short: "Summarize requests, optionally grouped by domain or content type",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			entries, err := parseHARFile(config.InputFile)
+			if err != nil {
+				return fmt.Errorf("error parsing HAR file: %w", err)
+			}
+			if filter != "" {
+				re, err := regexp.Compile(filter)
+				if err != nil {
+					return fmt.Errorf("invalid filter: %w", err)
+				}
+				entries = filterEntries(entries, []FilterFunc{func(e HAREntry) bool {
+					return re.MatchString(e.URL)
+				}}, "AND")
+			}
+
+			buckets, err := groupEntries(entries, groupBy)
+			if err != nil {
+				return err
+			}
+			if jsonOutput {
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				return enc.Encode(buckets)
+			}
+			return writeBuckets(cmd.OutOrStdout(), buckets)
+		},
+	}
+	cmd.Flags().StringVar(&groupBy, "group-by", "", "Group requests by (domain, type)")
+	cmd.Flags().StringVar(&filter, "filter", "", "Regular expression limiting analysis to matching URLs")
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Output JSON instead of text")
+	return cmd
+}
+
+// groupEntries buckets entries by the given key and summarizes each
+// bucket. Buckets are ordered by total size, largest first. An empty
+// groupBy yields a single "all" bucket.
+func groupEntries(entries []HAREntry, groupBy string) ([]BucketSummary, error) {
+	var keyFn func(HAREntry) string
+	switch groupBy {
+	case "":
+		keyFn = func(HAREntry) string { return "all" }
+	case "domain":
+		keyFn = entryDomain
+	case "type":
+		keyFn = func(e HAREntry) string { return resourceType(e.ContentType) }
+	default:
+		return nil, fmt.Errorf("invalid --group-by %q (want domain or type)", groupBy)
+	}
+
+	groups := make(map[string][]HAREntry)
+	for _, e := range entries {
+		k := keyFn(e)
+		groups[k] = append(groups[k], e)
+	}
+
+	buckets := make([]BucketSummary, 0, len(groups))
+	for k, group := range groups {
+		times := make([]float64, len(group))
+		var size int64
+		for i, e := range group {
+			times[i] = e.ResponseTime
+			size += e.ResponseSize
+		}
+		sort.Float64s(times)
+		buckets = append(buckets, BucketSummary{
+			Group:      k,
+			Count:      len(group),
+			TotalSize:  size,
+			MedianTime: percentile(times, 50),
+			P95Time:    percentile(times, 95),
+		})
+	}
+	sort.Slice(buckets, func(i, j int) bool {
+		if buckets[i].TotalSize != buckets[j].TotalSize {
+			return buckets[i].TotalSize > buckets[j].TotalSize
+		}
+		return buckets[i].Group < buckets[j].Group
+	})
+	return buckets, nil
+}
+
+// percentile returns the p-th percentile of sorted using the nearest-rank
+// method.
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[max(rank-1, 0)]
+}
+
+func entryDomain(e HAREntry) string {
+	u, err := url.Parse(e.URL)
+	if err != nil || u.Hostname() == "" {
+		return "(unknown)"
+	}
+	return u.Hostname()
+}
+
+// resourceType maps a MIME type to a coarse resource category.
+func resourceType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = strings.ToLower(strings.TrimSpace(contentType))
+	}
+	switch {
+	case mediaType == "":
+		return "other"
+	case mediaType == "text/html" || mediaType == "application/xhtml+xml":
+		return "document"
+	case mediaType == "text/css":
+		return "stylesheet"
+	case strings.Contains(mediaType, "javascript") || strings.Contains(mediaType, "ecmascript"):
+		return "script"
+	case strings.Contains(mediaType, "json") || strings.Contains(mediaType, "xml"):
+		return "data"
+	case strings.HasPrefix(mediaType, "image/"):
+		return "image"
+	case strings.HasPrefix(mediaType, "font/") || strings.Contains(mediaType, "font"):
+		return "font"
+	case strings.HasPrefix(mediaType, "audio/") || strings.HasPrefix(mediaType, "video/"):
+		return "media"
+	}
+	return "other"
+}
+
+func writeBuckets(w io.Writer, buckets []BucketSummary) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Group\tCount\tTotal Size\tMedian\tP95\n")
+	for _, b := range buckets {
+		fmt.Fprintf(tw, "%s\t%d\t%d bytes\t%.2f ms\t%.2f ms\n", b.Group, b.Count, b.TotalSize, b.MedianTime, b.P95Time)
+	}
+	return tw.Flush()
+}
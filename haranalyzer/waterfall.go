@@ -0,0 +1,205 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// waterfallPhases lists the request phases in the order they are drawn,
+// with the character used for each.
+var waterfallPhases = []struct {
+	name string
+	char byte
+	ms   func(Timings) float64
+}{
+	{"blocked", '-', func(t Timings) float64 { return t.Blocked }},
+	{"dns", 'd', func(t Timings) float64 { return t.DNS }},
+	{"connect", 'c', func(t Timings) float64 { return t.Connect }},
+	{"send", 's', func(t Timings) float64 { return t.Send }},
+	{"wait", 'w', func(t Timings) float64 { return t.Wait }},
+	{"receive", 'r', func(t Timings) float64 { return t.Receive }},
+}
+
+func newWaterfallCmd(config *Config) *cobra.Command {
+	var width int
+	cmd := &cobra.Command{
+		Use:   "waterfall",
+		Short: "Render an ASCII request waterfall from HAR timings",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			entries, err := parseHARFile(config.InputFile)
+			if err != nil {
+				return fmt.Errorf("error parsing HAR file: %w", err)
+			}
+			if width <= 0 {
+				width = terminalWidth()
+			}
+			return renderWaterfall(cmd.OutOrStdout(), entries, width)
+		},
+	}
+	cmd.Flags().IntVarP(&width, "width", "w", 0, "Chart width in columns (default: $COLUMNS or 120)")
+	return cmd
+}
+
+func terminalWidth() int {
+	if n, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && n > 0 {
+		return n
+	}
+	return 120
+}
+
+// duration returns the elapsed time of e in milliseconds, preferring the
+// sum of its phases over the reported total.
+func duration(e HAREntry) float64 {
+	var sum float64
+	for _, p := range waterfallPhases {
+		sum += p.ms(e.Timings)
+	}
+	if sum > 0 {
+		return sum
+	}
+	return e.ResponseTime
+}
+
+func endTime(e HAREntry) time.Time {
+	return e.StartedDateTime.Add(time.Duration(duration(e) * float64(time.Millisecond)))
+}
+
+// criticalPath returns the indexes of entries on the critical path: the
+// last request to finish, preceded by the latest request that finished
+// before it started, and so on back to the beginning of the page load.
+// Entries must be sorted by start time.
+func criticalPath(entries []HAREntry) map[int]bool {
+	path := make(map[int]bool)
+	if len(entries) == 0 {
+		return path
+	}
+	cur := 0
+	for i, e := range entries {
+		if endTime(e).After(endTime(entries[cur])) {
+			cur = i
+		}
+	}
+	for {
+		path[cur] = true
+		prev := -1
+		for i, e := range entries {
+			if path[i] || endTime(e).After(entries[cur].StartedDateTime) {
+				continue
+			}
+			if prev < 0 || endTime(e).After(endTime(entries[prev])) {
+				prev = i
+			}
+		}
+		if prev < 0 {
+			return path
+		}
+		cur = prev
+	}
+}
+
+func renderWaterfall(w io.Writer, entries []HAREntry, width int) error {
+	if len(entries) == 0 {
+		_, err := fmt.Fprintln(w, "No entries.")
+		return err
+	}
+
+	entries = append([]HAREntry(nil), entries...)
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].StartedDateTime.Before(entries[j].StartedDateTime)
+	})
+
+	start := entries[0].StartedDateTime
+	end := start
+	var totalSize int64
+	for _, e := range entries {
+		if t := endTime(e); t.After(end) {
+			end = t
+		}
+		totalSize += e.ResponseSize
+	}
+	totalMs := float64(end.Sub(start)) / float64(time.Millisecond)
+
+	const timeWidth = 9 // e.g. " 12345ms"
+	labelWidth := width / 3
+	if labelWidth > 50 {
+		labelWidth = 50
+	}
+	barWidth := width - labelWidth - timeWidth - 5 // marker, spaces, and bar borders
+	if barWidth < 10 {
+		barWidth = 10
+	}
+	scale := float64(barWidth) / math.Max(totalMs, 1)
+
+	fmt.Fprintf(w, "%d requests, %.0fms total, %d bytes\n", len(entries), totalMs, totalSize)
+	var legend []string
+	for _, p := range waterfallPhases {
+		legend = append(legend, fmt.Sprintf("%c %s", p.char, p.name))
+	}
+	fmt.Fprintf(w, "Legend: %s  * critical path\n\n", strings.Join(legend, "  "))
+
+	critical := criticalPath(entries)
+	for i, e := range entries {
+		marker := ' '
+		if critical[i] {
+			marker = '*'
+		}
+		offset := float64(e.StartedDateTime.Sub(start)) / float64(time.Millisecond)
+		bar := waterfallBar(e, offset, scale, barWidth)
+		if _, err := fmt.Fprintf(w, "%c %-*s |%s|%*.0fms\n", marker, labelWidth, truncate(e.Method+" "+e.URL, labelWidth), bar, timeWidth-2, duration(e)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// waterfallBar draws one request's phases starting offset milliseconds
+// into the chart. Positions are computed from cumulative times so that
+// rounding errors do not accumulate across phases.
+func waterfallBar(e HAREntry, offset, scale float64, barWidth int) string {
+	bar := []byte(strings.Repeat(" ", barWidth))
+	col := func(ms float64) int {
+		c := int(math.Round(ms * scale))
+		return min(max(c, 0), barWidth)
+	}
+
+	timings := e.Timings
+	if timings == (Timings{}) {
+		timings.Wait = e.ResponseTime // no phase breakdown available
+	}
+
+	pos := offset
+	drawn := false
+	for _, p := range waterfallPhases {
+		ms := p.ms(timings)
+		from, to := col(pos), col(pos+ms)
+		for c := from; c < to; c++ {
+			bar[c] = p.char
+			drawn = true
+		}
+		pos += ms
+	}
+	if !drawn {
+		// Keep very short or untimed requests visible.
+		c := min(col(offset), barWidth-1)
+		bar[c] = 'w'
+	}
+	return string(bar)
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	if n <= 3 {
+		return s[:n]
+	}
+	return s[:n-3] + "..."
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFetch(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/old", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/docs/page.html", http.StatusFound)
+	})
+	mux.HandleFunc("/docs/page.html", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("User-Agent"); got != "test-agent" {
+			t.Errorf("User-Agent = %q, want test-agent", got)
+		}
+		if got := r.Header.Get("X-Token"); got != "secret" {
+			t.Errorf("X-Token = %q, want secret", got)
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(`<a href="next.html">next</a>`))
+	})
+	mux.HandleFunc("/data.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{}`))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	headers := make(headerFlag)
+	if err := headers.Set("X-Token: secret"); err != nil {
+		t.Fatal(err)
+	}
+	cfg := fetchConfig{userAgent: "test-agent", headers: http.Header(headers)}
+
+	body, final, err := fetch(context.Background(), srv.URL+"/old", cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := final.String(), srv.URL+"/docs/page.html"; got != want {
+		t.Errorf("final URL = %q, want %q", got, want)
+	}
+	md, err := convert(strings.NewReader(string(body)), options{tableMode: tableFlatten, baseURL: final})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "[next](" + srv.URL + "/docs/next.html)"; !strings.Contains(md, want) {
+		t.Errorf("convert = %q, want it to contain %q", md, want)
+	}
+
+	_, _, err = fetch(context.Background(), srv.URL+"/data.json", fetchConfig{})
+	if err == nil || !strings.Contains(err.Error(), "not HTML") {
+		t.Errorf("fetch(data.json) error = %v, want not HTML error", err)
+	}
+	_, _, err = fetch(context.Background(), srv.URL+"/missing", fetchConfig{})
+	if err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("fetch(missing) error = %v, want 404 error", err)
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"net/url"
+	"strings"
+
+	md "github.com/JohannesKaufmann/html-to-markdown"
+	"github.com/PuerkitoBio/goquery"
+)
+
+// resolveLinks returns a plugin that rewrites relative href and src
+// attributes as absolute URLs against base. Absolute URLs, anchor-only
+// references, and URLs that fail to parse are left unchanged.
+func resolveLinks(base *url.URL) md.Plugin {
+	return func(c *md.Converter) []md.Rule {
+		c.Before(func(doc *goquery.Selection) {
+			for _, attr := range []string{"href", "src"} {
+				doc.Find("[" + attr + "]").Each(func(_ int, s *goquery.Selection) {
+					s.SetAttr(attr, resolveURL(base, s.AttrOr(attr, "")))
+				})
+			}
+		})
+		return nil
+	}
+}
+
+func resolveURL(base *url.URL, ref string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" || strings.HasPrefix(ref, "#") {
+		return ref
+	}
+	u, err := url.Parse(ref)
+	if err != nil || u.IsAbs() {
+		return ref
+	}
+	return base.ResolveReference(u).String()
+}
@@ -7,10 +7,29 @@ library with the GitHub Flavored Markdown plugin enabled by default.
 
 Usage:
 
-	html2md [-input=<filename>]
+	html2md [flags] [<file> | <url>]
 
-The -input flag specifies the input file. If omitted or set to "-", html2md
-reads from standard input.
+The input is read from the named file, or from standard input if none is
+given. The -input flag may also be used to name the input file; "-" means
+standard input.
+
+If the argument is an http:// or https:// URL, html2md fetches the page itself,
+following redirects. The -user-agent and -timeout flags control the request,
+and -header (repeatable) adds request headers such as "Cookie: a=b".
+Responses that are not HTML are rejected.
+
+Tables become GFM pipe tables, with column alignment taken from align
+attributes or text-align styles. The -table-mode flag controls tables with
+colspan or rowspan cells: "flatten" (the default) expands merged cells into
+empty ones, and "html" keeps such tables as raw HTML.
+
+The -base-url flag resolves relative links and image sources against the
+given URL, so that the Markdown stays usable outside the original site.
+When fetching a URL, the base URL defaults to the final URL of the page.
+
+The -readability flag extracts the main content of the page, dropping
+navigation, sidebars, and footers, before conversion. If too little content
+is found, the full page is converted instead; -v reports when this happens.
 
 html2md is designed to be simple and composable, following Unix philosophy. It
 can be easily integrated into pipelines or scripts for processing HTML content.
@@ -18,32 +37,91 @@ can be easily integrated into pipelines or scripts for processing HTML content.
 package main
 
 import (
+	"bytes"
+	"context"
 	"flag"
 	"fmt"
 	"io"
 	"log"
+	"net/http"
+	"net/url"
 	"os"
+	"time"
 
 	md "github.com/JohannesKaufmann/html-to-markdown"
 	"github.com/JohannesKaufmann/html-to-markdown/plugin"
 )
 
-var flagInput = flag.String("input", "-", "input file (default: stdin)")
+var (
+	flagInput     = flag.String("input", "-", "input file (default: stdin)")
+	flagTableMode = flag.String("table-mode", tableFlatten, "how to convert tables with merged cells (flatten, html)")
+	flagBaseURL   = flag.String("base-url", "", "resolve relative links against this URL (default: the fetched URL)")
+	flagUserAgent = flag.String("user-agent", "html2md/1.0", "User-Agent header for fetched URLs")
+	flagTimeout   = flag.Duration("timeout", 30*time.Second, "timeout for fetching a URL")
+	flagHeaders   = make(headerFlag)
+
+	flagReadability = flag.Bool("readability", false, "extract the main content before conversion")
+	flagVerbose     = flag.Bool("v", false, "verbose output")
+)
+
+func init() {
+	flag.Var(flagHeaders, "header", `request header for fetched URLs, as "Name: value" (repeatable)`)
+}
+
+// options configures a conversion.
+type options struct {
+	tableMode   string
+	baseURL     *url.URL // nil leaves relative links alone
+	readability bool
+	verbose     bool
+}
 
 func main() {
 	flag.Parse()
-	if flag.NArg() > 0 {
+	if flag.NArg() > 1 {
 		flag.Usage()
 		os.Exit(1)
 	}
-	if err := run(*flagInput); err != nil {
+	input := *flagInput
+	if flag.NArg() == 1 {
+		input = flag.Arg(0)
+	}
+	if *flagTableMode != tableFlatten && *flagTableMode != tableHTML {
+		log.Fatalf("invalid -table-mode %q (want flatten or html)", *flagTableMode)
+	}
+	opts := options{
+		tableMode:   *flagTableMode,
+		readability: *flagReadability,
+		verbose:     *flagVerbose,
+	}
+	if *flagBaseURL != "" {
+		u, err := url.Parse(*flagBaseURL)
+		if err != nil || !u.IsAbs() {
+			log.Fatalf("invalid -base-url %q: must be an absolute URL", *flagBaseURL)
+		}
+		opts.baseURL = u
+	}
+	if err := run(input, opts); err != nil {
 		log.Fatal(err)
 	}
 }
 
-func run(input string) error {
+func run(input string, opts options) error {
 	var r io.Reader
-	if input == "-" {
+	if isURL(input) {
+		body, final, err := fetch(context.Background(), input, fetchConfig{
+			userAgent: *flagUserAgent,
+			timeout:   *flagTimeout,
+			headers:   http.Header(flagHeaders),
+		})
+		if err != nil {
+			return err
+		}
+		if opts.baseURL == nil {
+			opts.baseURL = final
+		}
+		r = bytes.NewReader(body)
+	} else if input == "-" {
 		r = os.Stdin
 	} else {
 		f, err := os.Open(input)
@@ -54,7 +132,7 @@ func run(input string) error {
 		r = f
 	}
 
-	md, err := convert(r)
+	md, err := convert(r, opts)
 	if err != nil {
 		return err
 	}
@@ -62,9 +140,24 @@ func run(input string) error {
 	return nil
 }
 
-func convert(r io.Reader) (string, error) {
+func convert(r io.Reader, opts options) (string, error) {
+	if opts.readability {
+		page, err := io.ReadAll(r)
+		if err != nil {
+			return "", err
+		}
+		if article, ok := extractMainContent(page, opts); ok {
+			page = article
+		}
+		r = bytes.NewReader(page)
+	}
+
 	conv := md.NewConverter("", true, nil)
 	conv.Use(plugin.GitHubFlavored())
+	conv.Use(tableRules(opts.tableMode))
+	if opts.baseURL != nil {
+		conv.Use(resolveLinks(opts.baseURL))
+	}
 	markdown, err := conv.ConvertReader(r)
 	if err != nil {
 		return "", err
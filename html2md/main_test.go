@@ -0,0 +1,95 @@
+package main
+
+import (
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestConvertTables(t *testing.T) {
+	tests := []struct {
+		input, mode, want string
+	}{
+		{"testdata/table-simple.html", tableFlatten, "testdata/table-simple.md"},
+		{"testdata/table-simple.html", tableHTML, "testdata/table-simple.md"},
+		{"testdata/table-span.html", tableFlatten, "testdata/table-span.md"},
+		{"testdata/table-span.html", tableHTML, "testdata/table-span-html.md"},
+		{"testdata/table-span-gap.html", tableFlatten, "testdata/table-span-gap.md"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input+"/"+tt.mode, func(t *testing.T) {
+			f, err := os.Open(tt.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			got, err := convert(f, options{tableMode: tt.mode})
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, err := os.ReadFile(tt.want)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.TrimSpace(got) != strings.TrimSpace(string(want)) {
+				t.Errorf("convert(%s) =\n%s\nwant:\n%s", tt.input, got, want)
+			}
+		})
+	}
+}
+
+func TestBaseURL(t *testing.T) {
+	base, err := url.Parse("https://example.com/docs/guide/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	const input = `<p><a href="intro.html">intro</a> <a href="/about">about</a> <a href="#top">top</a>
+<a href="https://other.org/x">other</a> <a href="mailto:me@example.com">mail</a> <img src="../img/logo.png" alt="logo"></p>`
+	got, err := convert(strings.NewReader(input), options{tableMode: tableFlatten, baseURL: base})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"[intro](https://example.com/docs/guide/intro.html)",
+		"[about](https://example.com/about)",
+		"[top](#top)",
+		"[other](https://other.org/x)",
+		"[mail](mailto:me@example.com)",
+		"![logo](https://example.com/docs/img/logo.png)",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output missing %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestReadability(t *testing.T) {
+	f, err := os.Open("testdata/article.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	got, err := convert(f, options{tableMode: tableFlatten, readability: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(got, "# The longest migration") || !strings.Contains(got, "arctic terns") {
+		t.Errorf("article content missing:\n%s", got)
+	}
+	for _, noise := range []string{"About us", "Ten weird tricks"} {
+		if strings.Contains(got, noise) {
+			t.Errorf("output contains page chrome %q:\n%s", noise, got)
+		}
+	}
+
+	// Too little content to trust the extraction: convert the full page.
+	const short = `<nav><a href="/">Home</a></nav><p>Just a short note.</p>`
+	got, err = convert(strings.NewReader(short), options{tableMode: tableFlatten, readability: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(got, "[Home](/)") || !strings.Contains(got, "Just a short note.") {
+		t.Errorf("short page not converted in full:\n%s", got)
+	}
+}
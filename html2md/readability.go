@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"log"
+	"strings"
+
+	distiller "github.com/markusmobius/go-domdistiller"
+	nethtml "golang.org/x/net/html"
+)
+
+// minArticleWords is the smallest extracted word count that is trusted
+// as the main content of a page. Below it, the extraction most likely
+// missed the article and the full page is converted instead.
+const minArticleWords = 50
+
+// extractMainContent isolates the main content of page using the
+// DOM Distiller heuristics. It reports false if extraction fails or its
+// result is too small to be trusted.
+func extractMainContent(page []byte, opts options) ([]byte, bool) {
+	res, err := distiller.ApplyForReader(bytes.NewReader(page), &distiller.Options{
+		OriginalURL:    opts.baseURL,
+		SkipPagination: true,
+	})
+	if err != nil || res == nil || res.Node == nil {
+		if opts.verbose {
+			log.Printf("readability: extraction failed (%v); converting the full page", err)
+		}
+		return nil, false
+	}
+	if res.WordCount < minArticleWords {
+		if opts.verbose {
+			log.Printf("readability: only %d words extracted; converting the full page", res.WordCount)
+		}
+		return nil, false
+	}
+
+	var buf bytes.Buffer
+	if res.Title != "" && !containsHeading(res.Node) {
+		fmt.Fprintf(&buf, "<h1>%s</h1>\n", html.EscapeString(res.Title))
+	}
+	if err := nethtml.Render(&buf, res.Node); err != nil {
+		return nil, false
+	}
+	return buf.Bytes(), true
+}
+
+// containsHeading reports whether n contains an h1 element.
+func containsHeading(n *nethtml.Node) bool {
+	if n.Type == nethtml.ElementNode && strings.EqualFold(n.Data, "h1") {
+		return true
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if containsHeading(c) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	md "github.com/JohannesKaufmann/html-to-markdown"
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Table modes for tables with colspan or rowspan cells. Tables without
+// spans are always converted to GFM pipe tables.
+const (
+	tableFlatten = "flatten" // expand spans into empty cells
+	tableHTML    = "html"    // keep the table as raw HTML
+)
+
+// tableRules returns a plugin that prepares tables for GFM conversion.
+// It must be used after plugin.GitHubFlavored so that its rules take
+// precedence.
+func tableRules(mode string) md.Plugin {
+	return func(c *md.Converter) []md.Rule {
+		c.Before(func(doc *goquery.Selection) {
+			doc.Find("table").Each(func(_ int, table *goquery.Selection) {
+				if hasSpans(table) && mode == tableHTML {
+					return
+				}
+				flattenTable(table)
+				ensureHeader(table)
+				alignTable(table)
+			})
+		})
+		return []md.Rule{{
+			Filter: []string{"table"},
+			Replacement: func(content string, table *goquery.Selection, opt *md.Options) *string {
+				if mode != tableHTML || !hasSpans(table) {
+					return nil // use the GFM table rule
+				}
+				html, err := goquery.OuterHtml(table)
+				if err != nil {
+					return nil
+				}
+				return md.String("\n\n<!-- html2md: table has merged cells; kept as HTML -->\n" + html + "\n\n")
+			},
+		}}
+	}
+}
+
+// rows returns the rows of table, excluding those of nested tables.
+func rows(table *goquery.Selection) *goquery.Selection {
+	return table.Find("tr").FilterFunction(func(_ int, tr *goquery.Selection) bool {
+		return tr.Closest("table").IsSelection(table)
+	})
+}
+
+func hasSpans(table *goquery.Selection) bool {
+	spans := false
+	rows(table).Children().Each(func(_ int, cell *goquery.Selection) {
+		if span(cell, "colspan") > 1 || span(cell, "rowspan") > 1 {
+			spans = true
+		}
+	})
+	return spans
+}
+
+func span(cell *goquery.Selection, attr string) int {
+	n, err := strconv.Atoi(strings.TrimSpace(cell.AttrOr(attr, "1")))
+	if err != nil || n < 1 {
+		return 1
+	}
+	return n
+}
+
+// flattenTable rewrites table into a rectangular grid: a cell spanning
+// several columns or rows is followed by, or sits above, empty cells,
+// and short rows are padded.
+func flattenTable(table *goquery.Selection) {
+	pending := make(map[int]int) // column -> rows still covered by a rowspan
+	width := 0
+	rows(table).Each(func(_ int, tr *goquery.Selection) {
+		col := 0
+		fill := func(cell *goquery.Selection) {
+			for pending[col] > 0 {
+				pending[col]--
+				cell.BeforeHtml("<td></td>")
+				col++
+			}
+		}
+		tr.Children().Each(func(_ int, cell *goquery.Selection) {
+			fill(cell)
+			cols, rows := span(cell, "colspan"), span(cell, "rowspan")
+			cell.RemoveAttr("colspan")
+			cell.RemoveAttr("rowspan")
+			tag := goquery.NodeName(cell)
+			for i := 1; i < cols; i++ {
+				cell.AfterHtml(fmt.Sprintf("<%s></%s>", tag, tag))
+			}
+			if rows > 1 {
+				for i := 0; i < cols; i++ {
+					pending[col+i] = rows - 1
+				}
+			}
+			col += cols
+		})
+		// Columns still covered by rowspans from earlier rows may lie
+		// beyond a gap; fill the gap so that each keeps its column.
+		last := -1
+		for c, n := range pending {
+			if n > 0 && c >= col {
+				last = max(last, c)
+			}
+		}
+		for ; col <= last; col++ {
+			if pending[col] > 0 {
+				pending[col]--
+			}
+			tr.AppendHtml("<td></td>")
+		}
+		width = max(width, col)
+	})
+	rows(table).Each(func(_ int, tr *goquery.Selection) {
+		for n := tr.Children().Length(); n < width; n++ {
+			tr.AppendHtml("<td></td>")
+		}
+	})
+}
+
+// ensureHeader gives table an empty header row unless its first row is
+// already a header, since a GFM table cannot start with a body row.
+func ensureHeader(table *goquery.Selection) {
+	first := rows(table).First()
+	if first.Length() == 0 || first.Parent().Is("thead") {
+		return
+	}
+	allTH := first.Children().Length() > 0
+	first.Children().Each(func(_ int, cell *goquery.Selection) {
+		if goquery.NodeName(cell) != "th" {
+			allTH = false
+		}
+	})
+	if allTH {
+		return
+	}
+	header := "<thead><tr>" + strings.Repeat("<th></th>", first.Children().Length()) + "</tr></thead>"
+	if body := first.Parent(); body.Is("tbody") {
+		body.BeforeHtml(header)
+	} else {
+		first.BeforeHtml(header)
+	}
+}
+
+var textAlignRe = regexp.MustCompile(`(?i)text-align\s*:\s*(left|right|center)`)
+
+func cellAlign(cell *goquery.Selection) string {
+	if m := textAlignRe.FindStringSubmatch(cell.AttrOr("style", "")); m != nil {
+		return strings.ToLower(m[1])
+	}
+	return strings.ToLower(cell.AttrOr("align", ""))
+}
+
+// alignTable copies each column's alignment, taken from the first cell
+// in the column that declares one through an align attribute or a
+// text-align style, onto the header row, where the GFM table rule reads
+// it.
+func alignTable(table *goquery.Selection) {
+	all := rows(table)
+	aligns := make(map[int]string)
+	all.Each(func(_ int, tr *goquery.Selection) {
+		tr.Children().Each(func(i int, cell *goquery.Selection) {
+			if _, ok := aligns[i]; !ok {
+				if a := cellAlign(cell); a != "" {
+					aligns[i] = a
+				}
+			}
+		})
+	})
+	all.First().Children().Each(func(i int, cell *goquery.Selection) {
+		if a, ok := aligns[i]; ok {
+			cell.SetAttr("align", a)
+		}
+	})
+}